@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/errkind"
 	"github.com/dafraer/effective-mobile-task/store"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
@@ -18,6 +22,9 @@ const (
 	minLimit = 1
 	maxLimit = 1000
 	minAge   = 1
+
+	//Optional header with the user who provided or edited values, it is saved in their provenance
+	userHeader = "X-User"
 )
 
 type Service struct {
@@ -25,14 +32,57 @@ type Service struct {
 	logger   *zap.SugaredLogger
 	db       store.Storer
 	enricher enrich.Enricher
+	opts     Options
+}
+
+// Options configures the service
+type Options struct {
+	//Save people added with /add as pending and enrich them in the background
+	Async bool
+
+	//Background enrichment workers, zero values are replaced with the defaults
+	Workers      int
+	PollInterval time.Duration //how often idle workers look for pending people
+	Lease        time.Duration //how long a claimed person is hidden from other workers
+	MaxAttempts  int           //attempts before the person is dead-lettered
+	Backoff      time.Duration //delay before the first retry, doubled after each attempt
+	MaxBackoff   time.Duration
 }
 
+const (
+	DefaultWorkers      = 4
+	DefaultPollInterval = time.Second
+	DefaultLease        = time.Minute
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = time.Second * 10
+	DefaultMaxBackoff   = time.Minute * 10
+)
+
 // New returns a new service instance
-func New(logger *zap.SugaredLogger, db store.Storer, enricher enrich.Enricher) *Service {
+func New(logger *zap.SugaredLogger, db store.Storer, enricher enrich.Enricher, opts Options) *Service {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultLease
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
 	return &Service{
 		logger:   logger,
 		db:       db,
 		enricher: enricher,
+		opts:     opts,
 	}
 }
 
@@ -44,16 +94,32 @@ func (s *Service) Run(ctx context.Context, address string) error {
 		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
-	//Four REST routes
+	//Eight REST routes
 	// /get - get users with filters and pagination
+	// /people/{id} - get user by id
 	// /delete - delete user by id
 	// /update - update user data
 	// /add - add user
+	// /reenrich - enrich selected users again
+	// /enrich - preview enrichment of a user without saving them
+	// /provenance - get the source of enriched fields of a user
 	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 	http.HandleFunc("/get", s.getHandler)
+	http.HandleFunc("/people/{id}", s.personHandler)
 	http.HandleFunc("/delete", s.deleteHandler)
 	http.HandleFunc("/update", s.updateHandler)
 	http.HandleFunc("/add", s.addHandler)
+	http.HandleFunc("/reenrich", s.reenrichHandler)
+	http.HandleFunc("/enrich", s.enrichHandler)
+	http.HandleFunc("/provenance", s.provenanceHandler)
+
+	//Start background enrichment workers, they stop together with the server
+	if s.opts.Async {
+		workerCtx, cancel := context.WithCancel(ctx)
+		workers := s.runWorkers(workerCtx)
+		defer workers.Wait()
+		defer cancel()
+	}
 
 	//Create a channel to listen for errors
 	ch := make(chan error)
@@ -99,12 +165,16 @@ type getResponse struct {
 // @Produce      json
 // @Param        limit       query     int    true   "Number of items to return per page (must be between 1 and 100)" minimum(1) maximum(1000) example(10)
 // @Param        cursor      query     int    false  "Cursor for pagination (indicates the starting item index). Defaults to 0." minimum(0) example(0)
-// @Param        name        query     string false  "Filter by exact name (case-sensitive)" example(Ivan)
-// @Param        surname     query     string false  "Filter by exact surname (case-sensitive)" example(Ivanov)
-// @Param        patronymic  query     string false  "Filter by exact patronymic (case-sensitive)" example(Ivanovich)
+// @Param        name        query     string false  "Filter by name, normalized like the stored names so Иван, ivan and IVAN all match Ivan" example(Ivan)
+// @Param        surname     query     string false  "Filter by surname, normalized like the stored names" example(Ivanov)
+// @Param        patronymic  query     string false  "Filter by patronymic, normalized like the stored names" example(Ivanovich)
 // @Param        age         query     int    false  "Filter by exact age" minimum(1) example(30)
 // @Param        gender      query     string false  "Filter by gender (e.g., 'male', 'female')" example(male)
 // @Param        nationality query     string false  "Filter by nationality code" example(UA)
+// @Param        candidate   query     string false  "Filter by country code among nationality candidates" example(UA)
+// @Param        candidate_top query   int    false  "Only match candidate among the top N ranked candidates, requires candidate" minimum(1) example(3)
+// @Param        candidate_probability query number false "Only match candidate with probability greater than this value, requires candidate" minimum(0) maximum(1) example(0.2)
+// @Param        enrichment_status query string false "Filter by asynchronous enrichment status" Enums(pending, done, dead) example(pending)
 // @Success      200         {object}  getResponse "A paginated list of people and the cursor for the next page"
 // @Failure      400         {string}  string      "Bad Request: Invalid query parameter value or format (e.g., non-integer limit, limit out of range, negative age/cursor)."
 // @Failure      405         {string}  string      "Method Not Allowed: The HTTP method used is not GET."
@@ -123,56 +193,18 @@ func (s *Service) getHandler(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 	s.logger.Debugw("Request to getHandler", "query values", params)
 
-	//Parse limit
-	limitStr := params.Get("limit")
-	limit, err := strconv.Atoi(limitStr)
+	//Parse filters and pagination
+	storeParams, err := parseGetParams(params)
 	if err != nil {
-		http.Error(w, "Error converting limit to int", http.StatusBadRequest)
-		s.logger.Errorw("Error converting limit to int", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.logger.Errorw("Error parsing query parameters", "error", err)
 		return
 	}
 
-	//Check if limit value is correct
-	if limit < minLimit || limit > maxLimit {
-		http.Error(w, "limit must be in range [1; 1000]", http.StatusBadRequest)
-		return
-	}
-
-	//Parse cursor
-	cursorStr := params.Get("cursor")
-	cursor := 0
-	if cursorStr != "" {
-		cursor, err = strconv.Atoi(cursorStr)
-		if err != nil {
-			http.Error(w, "Error converting cursor to int", http.StatusBadRequest)
-			s.logger.Errorw("Error converting cursor to int", "error", err)
-			return
-		}
-	}
-
-	//Parse age
-	ageStr := params.Get("age")
-	age := 0
-	if ageStr != "" {
-		age, err = strconv.Atoi(ageStr)
-		if err != nil {
-			http.Error(w, "Error converting age to int", http.StatusBadRequest)
-			s.logger.Errorw("Error converting age to int", "error", err)
-			return
-		}
-		if age < minAge {
-			http.Error(w, "Age must be a positive integer", http.StatusBadRequest)
-			return
-		}
-	}
-
-	//put params into store.Params struct
-	storeParams := store.NewParams(limit, cursor, age, params.Get("name"), params.Get("surname"), params.Get("patronymic"), params.Get("gender"), params.Get("nationality"))
-
 	//Get the people from the database
 	people, err := s.db.GetPeople(r.Context(), storeParams)
 	if err != nil {
-		http.Error(w, "error getting people", http.StatusInternalServerError)
+		s.writeError(w, err, "error getting people")
 		s.logger.Errorw("Error getting people", "error", err)
 		return
 	}
@@ -192,6 +224,56 @@ func (s *Service) getHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
+// personHandler returns a person by id
+// @Summary      Get a person by ID
+// @Description  Retrieves a single person with all stored fields based on the ID in the path.
+// @Tags         People
+// @ID           get-person-by-id
+// @Produce      json
+// @Param        id   path      int    true  "ID of the person" example(123)
+// @Success      200  {object}  store.Person "The person"
+// @Failure      400  {string}  string "Bad Request: 'id' must be an integer."
+// @Failure      404  {string}  string "Not Found: There is no person with this ID."
+// @Failure      405  {string}  string "Method Not Allowed: The HTTP method used is not GET."
+// @Failure      500  {string}  string "Internal Server Error: Failed to retrieve the person from the database or failed to marshal the JSON response."
+// @Router       /people/{id} [get]
+func (s *Service) personHandler(w http.ResponseWriter, r *http.Request) {
+	s.logger.Infow("Request to personHandler")
+
+	//Check if the method is GET
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	//Get id from the path
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "id must be an integer", http.StatusBadRequest)
+		s.logger.Errorw("Error converting id to int", "error", err)
+		return
+	}
+	s.logger.Debugw("Request to personHandler", "id", id)
+
+	//Get the person from the database
+	person, err := s.db.GetPerson(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err, "error getting person")
+		s.logger.Errorw("Error getting person", "error", err)
+		return
+	}
+
+	//Write the person as a json response
+	resp, err := json.Marshal(person)
+	if err != nil {
+		http.Error(w, "error marshalling json", http.StatusInternalServerError)
+		s.logger.Errorw("Error marshalling json", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
 // deleteHandler deletes a person by id
 // @Summary      Delete a person by ID
 // @Description  Deletes a person record from the system based on the ID provided as a query parameter.
@@ -201,6 +283,7 @@ func (s *Service) getHandler(w http.ResponseWriter, r *http.Request) {
 // @Param        id   query     int    true  "ID of the person to delete" example(123)
 // @Success      200  {string}  string "Successfully deleted person (No content returned, only status)"
 // @Failure      400  {string}  string "Bad Request: 'id' query parameter is required or must be an integer."
+// @Failure      404  {string}  string "Not Found: There is no person with this ID."
 // @Failure      405  {string}  string "Method Not Allowed: The HTTP method used is not DELETE."
 // @Failure      500  {string}  string "Internal Server Error: Failed to delete the person from the database."
 // @Router       /delete [delete]
@@ -225,31 +308,34 @@ func (s *Service) deleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	//Delete person from the database
 	if err := s.db.DeletePerson(r.Context(), idInt); err != nil {
-		http.Error(w, "error deleting person", http.StatusInternalServerError)
+		s.writeError(w, err, "error deleting person")
 		s.logger.Errorw("Error deleting person", "error", err)
 	}
 }
 
+// updateRequest contains new person data, null age, gender or nationality mark the field as unknown
 type updateRequest struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Surname     string  `json:"surname"`
+	Patronymic  string  `json:"patronymic"`
+	Age         *int    `json:"age"`
+	Gender      *string `json:"gender"`
+	Nationality *string `json:"nationality"`
 }
 
 // updateHandler updates user by id
 // @Summary      Update a person's details
-// @Description  Updates fields for an existing person based on the provided data.
+// @Description  Updates fields for an existing person based on the provided data. Names are normalized the same way as in /add. Changed age, gender and nationality are saved with the status provided, so re-enrichment keeps them.
 // @Tags         People
 // @ID           update-person-details
 // @Accept       json
 // @Produce      plain
 // @Param        person body      updateRequest true "Person data to update. Include the ID of the person and the fields to change."
+// @Param        X-User header    string        false "User editing the person, saved in the provenance of changed age, gender and nationality" example(operator@example.com)
 // @Success      200    {string}  string      "Successfully updated person (No content returned, only status)"
-// @Failure      400    {string}  string      "Bad Request: Error decoding JSON request body."
+// @Failure      400    {string}  string      "Bad Request: Error decoding JSON request body, missing name, invalid age, gender or nationality, or a value is out of range."
+// @Failure      404    {string}  string      "Not Found: There is no person with this ID."
 // @Failure      405    {string}  string      "Method Not Allowed: The HTTP method must be PUT or PATCH."
 // @Failure      500    {string}  string      "Internal Server Error: Failed to update the person in the database."
 // @Router       /update [put]
@@ -272,17 +358,39 @@ func (s *Service) updateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	s.logger.Debugw("Request to updateHandler", "body", person)
 
-	//Update person
+	//Check the name and the edited values the same way as in addHandler
+	if err := validateAddRequest(addRequest{Name: person.Name, Age: person.Age, Gender: person.Gender, Nationality: person.Nationality}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if person.Nationality != nil {
+		nationality := enrich.NormalizeCountryID(*person.Nationality)
+		person.Nationality = &nationality
+	}
+
+	//Update person, names are normalized and the submitted spelling is kept as the original
+	//Edited fields get the provenance of the update and are marked as provided so that re-enrichment keeps them,
+	//the store keeps the old provenance and status for unchanged fields
+	provenance := &store.Provenance{Source: store.SourceUpdate, FetchedAt: time.Now(), EditedBy: r.Header.Get(userHeader)}
 	if err := s.db.UpdatePerson(r.Context(), &store.Person{
-		ID:          person.ID,
-		Name:        person.Name,
-		Surname:     person.Surname,
-		Patronymic:  person.Patronymic,
-		Age:         person.Age,
-		Gender:      person.Gender,
-		Nationality: person.Nationality,
+		ID:                    person.ID,
+		Name:                  enrich.NormalizeName(person.Name),
+		Surname:               enrich.NormalizeName(person.Surname),
+		Patronymic:            enrich.NormalizeName(person.Patronymic),
+		Age:                   person.Age,
+		Gender:                person.Gender,
+		Nationality:           person.Nationality,
+		NameOriginal:          person.Name,
+		SurnameOriginal:       person.Surname,
+		PatronymicOriginal:    person.Patronymic,
+		AgeProvenance:         provenance,
+		GenderProvenance:      provenance,
+		NationalityProvenance: provenance,
+		AgeStatus:             editedStatus(person.Age),
+		GenderStatus:          editedStatus(person.Gender),
+		NationalityStatus:     editedStatus(person.Nationality),
 	}); err != nil {
-		http.Error(w, "error editing person", http.StatusInternalServerError)
+		s.writeError(w, err, "error editing person")
 		s.logger.Errorw("Error editing person", "error", err)
 	}
 }
@@ -291,20 +399,47 @@ type addRequest struct {
 	Name       string `json:"name"`
 	Surname    string `json:"surname"`
 	Patronymic string `json:"patronymic"`
+	CountryID  string `json:"country_id"` //optional ISO 3166-1 alpha-2 code used to localize age and gender
+
+	//Optional values known by the client, they are saved as provided instead of being looked up
+	Age         *int    `json:"age"`
+	Gender      *string `json:"gender"`
+	Nationality *string `json:"nationality"`
+}
+
+// addResponse contains id of the added person and how the enrichment went
+// Status of each field is reported only when the person was enriched during the request
+type addResponse struct {
+	ID               int               `json:"id"`
+	EnrichmentStatus string            `json:"enrichment_status"`
+	Status           *enrichmentStatus `json:"status,omitempty"`
+}
+
+// enrichmentStatus is the status of each enriched field: ok, failed or skipped
+type enrichmentStatus struct {
+	Age         string `json:"age"`
+	Gender      string `json:"gender"`
+	Nationality string `json:"nationality"`
 }
 
 // addHandler enriches person and saves them to the database
 // @Summary      Add a new person after enrichment
-// @Description  Takes basic person details (name, surname, patronymic(optional)), enriches them with additional data (age, gender, nationality), saves the complete record to the database, and returns the newly generated ID.
+// @Description  Takes basic person details (name, surname, patronymic(optional), country_id(optional)) and optionally the known age, gender and nationality, which are saved with the status provided instead of being looked up, enriches them with additional data (age, gender, nationality), saves the record to the database, and returns the newly generated ID with the enrichment status of each field (ok, failed or skipped). If partial enrichment is enabled, the person is saved even if some fields failed to resolve. In asynchronous mode the person is saved with enrichment_status pending and 202 is returned immediately, the enrichment result is visible via /get. If country_id is set, age and gender are localized to that country. Names are trimmed, title-cased and transliterated from Cyrillic to Latin, the submitted spelling is saved as name_original, surname_original and patronymic_original.
 // @Tags         People
 // @ID           add-person
 // @Accept       json
 // @Produce      json
-// @Param        person body      addRequest true "Basic person details (name, surname, patronymic(optional)) to add and enrich."
-// @Success      200    {integer} integer     "Successfully added person, returns the new person's ID." example(12345) // Assuming ID is an integer
-// @Failure      400    {string}  string      "Bad Request: Error decoding JSON request body."
+// @Param        person body      addRequest true "Basic person details (name, surname, patronymic(optional)) to add and enrich, with optional known age, gender and nationality."
+// @Param        X-User header    string     false "User adding the person, saved in the provenance of provided age, gender and nationality" example(operator@example.com)
+// @Success      200    {object}  addResponse "Successfully added person, returns the new person's ID and the enrichment status of each field."
+// @Success      202    {object}  addResponse "Person is saved as pending and will be enriched in the background, returns the new person's ID."
+// @Failure      400    {string}  string      "Bad Request: Error decoding JSON request body, missing name, invalid country_id or invalid provided age, gender or nationality."
 // @Failure      405    {string}  string      "Method Not Allowed: The HTTP method must be POST."
+// @Failure      409    {string}  string      "Conflict: The person conflicts with data saved concurrently."
+// @Failure      429    {string}  string      "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header."
 // @Failure      500    {string}  string      "Internal Server Error: Failed to enrich person data or save the person to the database."
+// @Failure      502    {string}  string      "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name."
+// @Failure      503    {string}  string      "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures."
 // @Router       /add [post]
 func (s *Service) addHandler(w http.ResponseWriter, r *http.Request) {
 	s.logger.Infow("Request to addHandler")
@@ -324,32 +459,367 @@ func (s *Service) addHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	s.logger.Debugw("Request to addHandler", "body", person)
 
-	//Enrich person struct
-	p, err := s.enricher.EnrichPerson(r.Context(), person.Name, person.Surname, person.Patronymic)
+	//Check the name, the country hint and the provided values
+	if err := validateAddRequest(person); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved := newPerson(person, r.Header.Get(userHeader))
+
+	//In asynchronous mode the person is saved as pending and enriched by the workers
+	if s.opts.Async {
+		saved.EnrichmentStatus = store.EnrichmentPending
+		id, err := s.db.SavePerson(r.Context(), saved)
+		if err != nil {
+			s.writeError(w, err, "error saving person")
+			s.logger.Errorw("Error saving person", "error", err)
+			return
+		}
+		s.writeAddResponse(w, http.StatusAccepted, addResponse{ID: id, EnrichmentStatus: store.EnrichmentPending})
+		return
+	}
+
+	//Enrich person struct using the submitted spelling, provided values are not looked up
+	p, err := s.enricher.EnrichPerson(r.Context(), enrichInput(saved))
 	if err != nil {
-		http.Error(w, "error enriching person", http.StatusInternalServerError)
+		s.writeError(w, err, "error enriching person")
 		s.logger.Errorw("Error enriching person", "error", err)
 		return
 	}
 
 	//Insert the person into the database
-	id, err := s.db.SavePerson(r.Context(), &store.Person{
-		Name:        p.Name,
-		Surname:     p.Surname,
-		Patronymic:  p.Patronymic,
-		Age:         p.Age,
-		Gender:      p.Gender,
-		Nationality: p.Nationality,
-	})
+	setEnrichment(saved, p)
+	saved.EnrichmentStatus = store.EnrichmentDone
+	id, err := s.db.SavePerson(r.Context(), saved)
 	if err != nil {
-		http.Error(w, "error saving person", http.StatusInternalServerError)
+		s.writeError(w, err, "error saving person")
 		s.logger.Errorw("Error saving person", "error", err)
 		return
 	}
 
-	//Write the id as a response
+	//Write the id and the enrichment status as a response
+	s.writeAddResponse(w, http.StatusOK, addResponse{ID: id, EnrichmentStatus: store.EnrichmentDone, Status: &enrichmentStatus{
+		Age:         string(p.AgeStatus),
+		Gender:      string(p.GenderStatus),
+		Nationality: string(p.NationalityStatus),
+	}})
+}
+
+// enrichHandler enriches person without saving them
+// @Summary      Preview enrichment of a person
+// @Description  Enriches person details (name, surname, patronymic(optional), country_id(optional)) with age, gender and nationality together with their probabilities and returns the result without saving it. Accepts the details either as query parameters (GET) or as the same JSON body as /add (POST). Results are cached, so adding the same person with /add afterwards does not call the providers again.
+// @Tags         People
+// @ID           enrich-person
+// @Accept       json
+// @Produce      json
+// @Param        name        query     string false "Name of the person, required for GET" example(Ivan)
+// @Param        surname     query     string false "Surname of the person" example(Ivanov)
+// @Param        patronymic  query     string false "Patronymic of the person" example(Ivanovich)
+// @Param        country_id  query     string false "Two-letter country code used to localize age and gender" example(UA)
+// @Param        person body      addRequest false "Person details for POST, the same as for /add."
+// @Success      200    {object}  enrich.Person "Enriched person with the probabilities and the status of each field."
+// @Failure      400    {string}  string      "Bad Request: Error decoding JSON request body, missing name or invalid country_id."
+// @Failure      405    {string}  string      "Method Not Allowed: The HTTP method must be GET or POST."
+// @Failure      429    {string}  string      "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header."
+// @Failure      500    {string}  string      "Internal Server Error: Failed to enrich person data."
+// @Failure      502    {string}  string      "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name."
+// @Failure      503    {string}  string      "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures."
+// @Router       /enrich [get]
+// @Router       /enrich [post]
+func (s *Service) enrichHandler(w http.ResponseWriter, r *http.Request) {
+	s.logger.Infow("Request to enrichHandler")
+
+	//Parse the person from the query parameters or from the request body
+	var person addRequest
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+		person = addRequest{Name: params.Get("name"), Surname: params.Get("surname"), Patronymic: params.Get("patronymic"), CountryID: params.Get("country_id")}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&person); err != nil {
+			http.Error(w, "error decoding json", http.StatusBadRequest)
+			s.logger.Errorw("Error decoding json", "error", err)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.logger.Debugw("Request to enrichHandler", "person", person)
+
+	//Check the name, the country hint and the provided values
+	if err := validateAddRequest(person); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//Enrich the person the same way as addHandler does so that the cached result is shared
+	p, err := s.enricher.EnrichPerson(r.Context(), enrichInput(newPerson(person, "")))
+	if err != nil {
+		s.writeError(w, err, "error enriching person")
+		s.logger.Errorw("Error enriching person", "error", err)
+		return
+	}
+
+	//Write the enriched person as a response
+	resp, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, "error marshalling json", http.StatusInternalServerError)
+		s.logger.Errorw("Error marshalling json", "error", err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	resp, err := json.Marshal(id)
-	s.logger.Debugw("Response from addHandler", "response", id)
 	w.Write(resp)
 }
+
+// writeAddResponse writes the response of addHandler with the status code
+func (s *Service) writeAddResponse(w http.ResponseWriter, code int, response addResponse) {
+	resp, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "error marshalling json", http.StatusInternalServerError)
+		s.logger.Errorw("Error marshalling json", "error", err)
+		return
+	}
+	s.logger.Debugw("Response from addHandler", "response", response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(resp)
+}
+
+// validateAddRequest checks the name, the country hint and the values provided by the client
+// The name is checked before saving so that asynchronous mode rejects the same requests as synchronous mode
+func validateAddRequest(person addRequest) error {
+	if enrich.NormalizeName(person.Name) == "" {
+		return errors.New("name is required")
+	}
+	if person.CountryID != "" && !enrich.IsCountryID(enrich.NormalizeCountryID(person.CountryID)) {
+		return errors.New("country_id must be a two-letter country code")
+	}
+	if person.Age != nil && *person.Age < minAge {
+		return errors.New("age must be a positive integer")
+	}
+	if person.Gender != nil && *person.Gender != "male" && *person.Gender != "female" {
+		return errors.New("gender must be male or female")
+	}
+	if person.Nationality != nil && !enrich.IsCountryID(enrich.NormalizeCountryID(*person.Nationality)) {
+		return errors.New("nationality must be a two-letter country code")
+	}
+	return nil
+}
+
+// newPerson returns the person to be saved from the request
+// The name is normalized and the submitted spelling is kept as the original, values provided by the client are marked as provided
+// and get the client as their provenance, editedBy is the user who sent them if it is known
+func newPerson(person addRequest, editedBy string) *store.Person {
+	saved := &store.Person{
+		Name:               enrich.NormalizeName(person.Name),
+		Surname:            enrich.NormalizeName(person.Surname),
+		Patronymic:         enrich.NormalizeName(person.Patronymic),
+		CountryHint:        enrich.NormalizeCountryID(person.CountryID),
+		NameOriginal:       person.Name,
+		SurnameOriginal:    person.Surname,
+		PatronymicOriginal: person.Patronymic,
+	}
+	provenance := &store.Provenance{Source: store.SourceClient, FetchedAt: time.Now(), EditedBy: editedBy}
+	if person.Age != nil {
+		saved.Age, saved.AgeStatus, saved.AgeProvenance = person.Age, string(enrich.StatusProvided), provenance
+	}
+	if person.Gender != nil {
+		saved.Gender, saved.GenderStatus, saved.GenderProvenance = person.Gender, string(enrich.StatusProvided), provenance
+	}
+	if person.Nationality != nil {
+		nationality := enrich.NormalizeCountryID(*person.Nationality)
+		saved.Nationality, saved.NationalityStatus, saved.NationalityProvenance = &nationality, string(enrich.StatusProvided), provenance
+	}
+	return saved
+}
+
+// editedStatus returns the status of a field set with /update, cleared fields keep their status
+func editedStatus[T any](value *T) string {
+	if value == nil {
+		return ""
+	}
+	return string(enrich.StatusProvided)
+}
+
+// enrichInput returns the enrichment input of the stored person
+// The name is passed in its original spelling if it is known, the enricher normalizes it again
+// Fields marked as provided are passed as known so that providers are not asked about them
+func enrichInput(person *store.Person) enrich.Input {
+	in := enrich.Input{
+		Name:       person.Name,
+		Surname:    person.Surname,
+		Patronymic: person.Patronymic,
+		CountryID:  person.CountryHint,
+	}
+	if person.NameOriginal != "" {
+		in.Name, in.Surname, in.Patronymic = person.NameOriginal, person.SurnameOriginal, person.PatronymicOriginal
+	}
+	if person.AgeStatus == string(enrich.StatusProvided) {
+		in.Age = person.Age
+	}
+	if person.GenderStatus == string(enrich.StatusProvided) {
+		in.Gender = person.Gender
+	}
+	if person.NationalityStatus == string(enrich.StatusProvided) {
+		in.Nationality = person.Nationality
+	}
+	return in
+}
+
+// setEnrichment copies enriched fields of p into the stored person and sets the enrichment time
+// Provenance of the fields provided by the client is kept
+func setEnrichment(person *store.Person, p *enrich.Person) {
+	person.Age = p.Age
+	person.Gender = p.Gender
+	person.Nationality = p.Nationality
+	person.CountryHint = p.CountryHint
+	person.AgeSampleCount = p.AgeSampleCount
+	person.GenderProbability = p.GenderProbability
+	person.NationalityProbability = p.NationalityProbability
+	person.NationalityCandidates = nationalityCandidates(p.NationalityCandidates)
+	person.AgeStatus = string(p.AgeStatus)
+	person.GenderStatus = string(p.GenderStatus)
+	person.NationalityStatus = string(p.NationalityStatus)
+	if p.AgeStatus != enrich.StatusProvided {
+		person.AgeProvenance = storeProvenance(p.AgeProvenance)
+	}
+	if p.GenderStatus != enrich.StatusProvided {
+		person.GenderProvenance = storeProvenance(p.GenderProvenance)
+	}
+	if p.NationalityStatus != enrich.StatusProvided {
+		person.NationalityProvenance = storeProvenance(p.NationalityProvenance)
+	}
+	enrichedAt := time.Now()
+	person.EnrichedAt = &enrichedAt
+}
+
+// storeProvenance converts the provenance of an enriched field to the stored one
+func storeProvenance(p *enrich.Provenance) *store.Provenance {
+	if p == nil {
+		return nil
+	}
+	return &store.Provenance{Source: p.Source, FetchedAt: p.FetchedAt, Probability: p.Probability, SampleCount: p.SampleCount}
+}
+
+// writeError writes an error response matching the kind of the store or enrichment error
+// Messages of not found, conflict and validation errors are shown to the client, message is written for unexpected errors
+func (s *Service) writeError(w http.ResponseWriter, err error, message string) {
+	var rateLimitErr *enrich.RateLimitError
+	var circuitErr *enrich.CircuitOpenError
+	switch {
+	case errors.Is(err, errkind.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errkind.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errkind.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &rateLimitErr):
+		setRetryAfter(w, rateLimitErr.Reset)
+		http.Error(w, "enrichment quota exhausted", http.StatusTooManyRequests)
+	case errors.As(err, &circuitErr):
+		setRetryAfter(w, circuitErr.Until)
+		http.Error(w, "enrichment provider unavailable", http.StatusServiceUnavailable)
+	case errors.Is(err, enrich.ErrInvalidResponse):
+		http.Error(w, "invalid response from enrichment provider", http.StatusBadGateway)
+	case errors.Is(err, enrich.ErrNoResult):
+		http.Error(w, "no enrichment provider has a result for the name", http.StatusBadGateway)
+	case errors.Is(err, errkind.ErrUpstream):
+		http.Error(w, "enrichment provider unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// setRetryAfter sets Retry-After header to the number of seconds until t
+func setRetryAfter(w http.ResponseWriter, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	retryAfter := int(math.Ceil(time.Until(t).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+}
+
+// nationalityCandidates converts enriched countries into candidates stored in the database
+func nationalityCandidates(countries []enrich.Country) []store.NationalityCandidate {
+	if len(countries) == 0 {
+		return nil
+	}
+	candidates := make([]store.NationalityCandidate, 0, len(countries))
+	for _, c := range countries {
+		candidates = append(candidates, store.NationalityCandidate{CountryID: c.CountryID, Probability: c.Probability})
+	}
+	return candidates
+}
+
+// parseGetParams parses filters and pagination of /get, the error message is meant for the client
+func parseGetParams(params url.Values) (*store.GetParams, error) {
+	//Parse limit
+	limit, err := strconv.Atoi(params.Get("limit"))
+	if err != nil {
+		return nil, errors.New("Error converting limit to int")
+	}
+
+	//Check if limit value is correct
+	if limit < minLimit || limit > maxLimit {
+		return nil, errors.New("limit must be in range [1; 1000]")
+	}
+
+	//Parse cursor
+	cursorStr := params.Get("cursor")
+	cursor := 0
+	if cursorStr != "" {
+		cursor, err = strconv.Atoi(cursorStr)
+		if err != nil {
+			return nil, errors.New("Error converting cursor to int")
+		}
+	}
+
+	//Parse age
+	ageStr := params.Get("age")
+	age := 0
+	if ageStr != "" {
+		age, err = strconv.Atoi(ageStr)
+		if err != nil {
+			return nil, errors.New("Error converting age to int")
+		}
+		if age < minAge {
+			return nil, errors.New("Age must be a positive integer")
+		}
+	}
+
+	//put params into store.Params struct, names and countries are normalized the same way as when the person was added
+	storeParams := store.NewParams(limit, cursor, age, enrich.NormalizeName(params.Get("name")), enrich.NormalizeName(params.Get("surname")),
+		enrich.NormalizeName(params.Get("patronymic")), params.Get("gender"), enrich.NormalizeCountryID(params.Get("nationality")))
+
+	//Parse nationality candidate filter
+	if candidate := enrich.NormalizeCountryID(params.Get("candidate")); candidate != "" {
+		storeParams.Candidate = &candidate
+	}
+	if topStr := params.Get("candidate_top"); topStr != "" {
+		top, err := strconv.Atoi(topStr)
+		if err != nil || top < 1 {
+			return nil, errors.New("candidate_top must be a positive integer")
+		}
+		storeParams.CandidateTop = &top
+	}
+	if probabilityStr := params.Get("candidate_probability"); probabilityStr != "" {
+		probability, err := strconv.ParseFloat(probabilityStr, 64)
+		if err != nil || probability < 0 || probability > 1 {
+			return nil, errors.New("candidate_probability must be a number in range [0; 1]")
+		}
+		storeParams.CandidateProbability = &probability
+	}
+	if storeParams.Candidate == nil && (storeParams.CandidateTop != nil || storeParams.CandidateProbability != nil) {
+		return nil, errors.New("candidate_top and candidate_probability require candidate")
+	}
+
+	//Parse enrichment status filter
+	if status := params.Get("enrichment_status"); status != "" {
+		if status != store.EnrichmentPending && status != store.EnrichmentDone && status != store.EnrichmentDead {
+			return nil, errors.New("enrichment_status must be one of pending, done, dead")
+		}
+		storeParams.EnrichmentStatus = &status
+	}
+	return storeParams, nil
+}
@@ -2,14 +2,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/errkind"
 	"github.com/dafraer/effective-mobile-task/store"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -22,7 +27,7 @@ func TestGetHandler(t *testing.T) {
 	sugar := logger.Sugar()
 
 	//Create new server for testing
-	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher())
+	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher(), Options{})
 
 	//Create test server
 	server := httptest.NewServer(http.HandlerFunc(service.getHandler))
@@ -82,6 +87,9 @@ func TestGetHandler(t *testing.T) {
 	params.Add("patronymic", "Ivanovich")
 	params.Add("gender", "male")
 	params.Add("nationality", "russian")
+	params.Add("candidate", "UA")
+	params.Add("candidate_top", "3")
+	params.Add("candidate_probability", "0.2")
 
 	//Add query values to the URL string
 	server.URL = srvUrl + params.Encode()
@@ -106,9 +114,26 @@ func TestGetHandler(t *testing.T) {
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         30,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         ptr(30),
+		Gender:      ptr("male"),
+		Nationality: ptr("russian"),
+
+		NameOriginal:       "Иван",
+		SurnameOriginal:    "Иванов",
+		PatronymicOriginal: "Иванович",
+
+		AgeSampleCount:         1000,
+		GenderProbability:      0.99,
+		NationalityProbability: 0.4,
+		NationalityCandidates:  []store.NationalityCandidate{{CountryID: "RU", Probability: 0.4}, {CountryID: "UA", Probability: 0.2}},
+		AgeStatus:              "ok",
+		GenderStatus:           "ok",
+		NationalityStatus:      "provided",
+		EnrichmentStatus:       "done",
+
+		AgeProvenance:         &store.Provenance{Source: "agify", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), SampleCount: 1000},
+		GenderProvenance:      &store.Provenance{Source: "genderize", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Probability: 0.99},
+		NationalityProvenance: &store.Provenance{Source: "update", FetchedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), EditedBy: "operator"},
 	}
 	assert.Equal(t, *response.NextCursor, 1)
 	assert.EqualValues(t, person, *response.People[0])
@@ -124,7 +149,7 @@ func TestAddHandler(t *testing.T) {
 	sugar := logger.Sugar()
 
 	//Create new server for testing
-	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher())
+	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher(), Options{})
 
 	//Create test server
 	server := httptest.NewServer(http.HandlerFunc(service.addHandler))
@@ -157,14 +182,120 @@ func TestAddHandler(t *testing.T) {
 	//Check that status code is 200
 	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
 
-	//Check that response has correct id
-	var id int
-	err = json.NewDecoder(resp.Body).Decode(&id)
-	assert.Equal(t, 1, id)
+	//Check that response has correct id and status
+	var response addResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, addResponse{ID: 1, EnrichmentStatus: "done", Status: &enrichmentStatus{Age: "ok", Gender: "ok", Nationality: "ok"}}, response)
+	//Close response body
+	assert.NoError(t, resp.Body.Close())
+
+	//Make a POST request with invalid country hint
+	body, err = json.Marshal(addRequest{Name: "Ivan", Surname: "Ivanov", CountryID: "Ukraine"})
+	assert.NoError(t, err)
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+
+	//Check that status code is 400
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 but got %d", resp.StatusCode))
+
 	//Close response body
 	assert.NoError(t, resp.Body.Close())
 }
 
+// failingEnricher returns the configured error from EnrichPerson
+type failingEnricher struct {
+	enrich.MockEnricher
+	err error
+}
+
+func (e *failingEnricher) EnrichPerson(ctx context.Context, in enrich.Input) (*enrich.Person, error) {
+	return nil, e.err
+}
+
+func TestAddHandlerEnrichErrors(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	sugar := logger.Sugar()
+
+	body, err := json.Marshal(addRequest{Name: "Ivan", Surname: "Ivanov"})
+	assert.NoError(t, err)
+
+	//Rate limited provider results in 429 with Retry-After header
+	rateLimited := &failingEnricher{err: &enrich.RateLimitError{Provider: "agify", Reset: time.Now().Add(time.Minute)}}
+	server := httptest.NewServer(http.HandlerFunc(New(sugar, store.NewMockStore(), rateLimited, Options{}).addHandler))
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, fmt.Sprintf("expected 429 but got %d", resp.StatusCode))
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.NoError(t, resp.Body.Close())
+
+	//Provider error results in 503
+	unavailable := &failingEnricher{err: &enrich.StatusError{Provider: "agify", StatusCode: http.StatusBadGateway}}
+	server = httptest.NewServer(http.HandlerFunc(New(sugar, store.NewMockStore(), unavailable, Options{}).addHandler))
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, fmt.Sprintf("expected 503 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Invalid provider response results in 502
+	invalid := &failingEnricher{err: &enrich.DecodeError{Provider: "agify", Reason: "unexpected content type \"text/html\""}}
+	server = httptest.NewServer(http.HandlerFunc(New(sugar, store.NewMockStore(), invalid, Options{}).addHandler))
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode, fmt.Sprintf("expected 502 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+}
+
+// savingStore remembers the last saved person
+type savingStore struct {
+	store.MockStore
+	saved *store.Person
+}
+
+func (s *savingStore) SavePerson(ctx context.Context, person *store.Person) (int, error) {
+	s.saved = person
+	return 1, nil
+}
+
+// echoEnricher returns the input it was called with and remembers it
+type echoEnricher struct {
+	enrich.MockEnricher
+	in enrich.Input
+}
+
+func (e *echoEnricher) EnrichPerson(ctx context.Context, in enrich.Input) (*enrich.Person, error) {
+	e.in = in
+	return &enrich.Person{Name: in.Name, Surname: in.Surname, Patronymic: in.Patronymic}, nil
+}
+
+func TestAddHandlerNormalizesNames(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	db := &savingStore{}
+	enricher := &echoEnricher{}
+	server := httptest.NewServer(http.HandlerFunc(New(logger.Sugar(), db, enricher, Options{}).addHandler))
+	body, err := json.Marshal(addRequest{Name: " иван", Surname: "ИВАНОВ", Patronymic: "ivanovich"})
+	assert.NoError(t, err)
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Normalized name is saved, submitted spelling is kept
+	assert.Equal(t, "Ivan", db.saved.Name)
+	assert.Equal(t, "Ivanov", db.saved.Surname)
+	assert.Equal(t, "Ivanovich", db.saved.Patronymic)
+	assert.Equal(t, " иван", db.saved.NameOriginal)
+	assert.Equal(t, "ИВАНОВ", db.saved.SurnameOriginal)
+	assert.Equal(t, "ivanovich", db.saved.PatronymicOriginal)
+
+	//Enricher gets the submitted spelling so that rules for the script can apply
+	assert.Equal(t, "ИВАНОВ", enricher.in.Surname)
+}
+
 func TestUpdateHandler(t *testing.T) {
 	//Create logger
 	logger, err := zap.NewDevelopment()
@@ -172,7 +303,7 @@ func TestUpdateHandler(t *testing.T) {
 	sugar := logger.Sugar()
 
 	//Create new server for testing
-	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher())
+	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher(), Options{})
 
 	//Create test server
 	server := httptest.NewServer(http.HandlerFunc(service.updateHandler))
@@ -195,9 +326,9 @@ func TestUpdateHandler(t *testing.T) {
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         14,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         ptr(14),
+		Gender:      ptr("male"),
+		Nationality: ptr("RU"),
 	}
 	body, err := json.Marshal(requestBody)
 	assert.NoError(t, err)
@@ -211,6 +342,21 @@ func TestUpdateHandler(t *testing.T) {
 
 	//Close response body
 	assert.NoError(t, resp.Body.Close())
+
+	//Make a PUT request for a person that does not exist
+	requestBody.ID = 2
+	body, err = json.Marshal(requestBody)
+	assert.NoError(t, err)
+	req, err = http.NewRequest(http.MethodPut, server.URL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	//Check that status code is 404
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, fmt.Sprintf("expected 404 but got %d", resp.StatusCode))
+
+	//Close response body
+	assert.NoError(t, resp.Body.Close())
 }
 
 func TestDeleteHandler(t *testing.T) {
@@ -220,7 +366,7 @@ func TestDeleteHandler(t *testing.T) {
 	sugar := logger.Sugar()
 
 	//Create new server for testing
-	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher())
+	service := New(sugar, store.NewMockStore(), enrich.NewMockEnricher(), Options{})
 
 	//Create test server
 	server := httptest.NewServer(http.HandlerFunc(service.deleteHandler))
@@ -249,4 +395,337 @@ func TestDeleteHandler(t *testing.T) {
 
 	//Close response body
 	assert.NoError(t, resp.Body.Close())
+
+	//Make a DELETE request for a person that does not exist
+	req, err = http.NewRequest(http.MethodDelete, strings.Replace(server.URL, "?id=1", "?id=2", 1), http.NoBody)
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	//Check that status code is 404
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, fmt.Sprintf("expected 404 but got %d", resp.StatusCode))
+
+	//Close response body
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestWriteError(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	service := New(logger.Sugar(), store.NewMockStore(), enrich.NewMockEnricher(), Options{})
+
+	//Each kind of error is written with its status code
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("error deleting: %w", store.ErrNotFound), http.StatusNotFound},
+		{errkind.New(errkind.ErrConflict, "duplicate key", nil), http.StatusConflict},
+		{errkind.New(errkind.ErrValidation, "integer out of range", nil), http.StatusBadRequest},
+		{fmt.Errorf("%w: name is required", enrich.ErrInvalidInput), http.StatusBadRequest},
+		{&enrich.RateLimitError{Provider: "agify"}, http.StatusTooManyRequests},
+		{&enrich.CircuitOpenError{Provider: "agify"}, http.StatusServiceUnavailable},
+		{&enrich.DecodeError{Provider: "agify", Reason: "malformed json"}, http.StatusBadGateway},
+		{&enrich.StatusError{Provider: "agify", StatusCode: http.StatusBadGateway}, http.StatusServiceUnavailable},
+		{&enrich.ProviderError{Provider: "agify", Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+		{fmt.Errorf("no age for %q: %w", "Ivan", enrich.ErrNoResult), http.StatusBadGateway},
+		{errors.New("unexpected"), http.StatusInternalServerError},
+	} {
+		w := httptest.NewRecorder()
+		service.writeError(w, tc.err, "error")
+		assert.Equal(t, tc.code, w.Code, fmt.Sprintf("expected %d for %v but got %d", tc.code, tc.err, w.Code))
+	}
+
+	//Messages of expected errors are shown to the client
+	w := httptest.NewRecorder()
+	service.writeError(w, store.ErrNotFound, "error")
+	assert.Equal(t, "person not found\n", w.Body.String())
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// countingEnricher counts enriched people
+type countingEnricher struct {
+	enrich.MockEnricher
+	calls int
+}
+
+func (e *countingEnricher) EnrichPerson(ctx context.Context, in enrich.Input) (*enrich.Person, error) {
+	e.calls++
+	age := 42
+	return &enrich.Person{Name: in.Name, Surname: in.Surname, Age: &age, AgeSampleCount: 1000, AgeStatus: enrich.StatusOK}, nil
+}
+
+func TestEnrichHandler(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	//Enricher is wrapped with the cache the same way as in main
+	next := &countingEnricher{}
+	db := &savingStore{}
+	service := New(logger.Sugar(), db, enrich.NewCachingEnricher(next, logger.Sugar(), enrich.CacheOptions{}), Options{})
+	server := httptest.NewServer(http.HandlerFunc(service.enrichHandler))
+	t.Cleanup(server.Close)
+
+	//Person is enriched from the query parameters and is not saved
+	resp, err := http.Get(server.URL + "?name=иван&surname=Ivanov")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	var person enrich.Person
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&person))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, "Ivan", person.Name)
+	assert.Equal(t, 42, *person.Age)
+	assert.Equal(t, 1000, person.AgeSampleCount)
+	assert.Nil(t, db.saved)
+
+	//Person is enriched from the body, the cached result is used
+	body, err := json.Marshal(addRequest{Name: "Ivan", Surname: "Petrov"})
+	assert.NoError(t, err)
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Adding the previewed person does not call the enricher again
+	add := httptest.NewServer(http.HandlerFunc(service.addHandler))
+	t.Cleanup(add.Close)
+	resp, err = http.Post(add.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, 1, next.calls)
+	assert.Equal(t, 42, *db.saved.Age)
+
+	//Missing name and invalid country result in 400
+	for _, query := range []string{"?surname=Ivanov", "?name=Ivan&country_id=UKR"} {
+		resp, err = http.Get(server.URL + query)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 for %s but got %d", query, resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	//Only GET and POST are allowed
+	req, err := http.NewRequest(http.MethodDelete, server.URL, nil)
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, fmt.Sprintf("expected 405 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestAddHandlerProvidedValues(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	//Enricher without providers resolves only the provided values
+	db := &savingStore{}
+	enricher := enrich.New(logger.Sugar(), enrich.Providers{}, enrich.Options{AllowPartial: true})
+	server := httptest.NewServer(http.HandlerFunc(New(logger.Sugar(), db, enricher, Options{}).addHandler))
+	t.Cleanup(server.Close)
+
+	//Provided values are saved and marked as provided, the rest is left to the providers
+	age, nationality := 35, "ua"
+	body, err := json.Marshal(addRequest{Name: "Ivan", Surname: "Ivanov", Age: &age, Nationality: &nationality})
+	assert.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("X-User", "operator")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	var response addResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, &enrichmentStatus{Age: "provided", Gender: "skipped", Nationality: "provided"}, response.Status)
+	assert.Equal(t, 35, *db.saved.Age)
+	assert.Equal(t, "UA", *db.saved.Nationality)
+	assert.Nil(t, db.saved.Gender)
+	assert.Equal(t, "provided", db.saved.AgeStatus)
+	assert.Equal(t, "skipped", db.saved.GenderStatus)
+
+	//Provided values get the client as their provenance
+	assert.Equal(t, store.SourceClient, db.saved.AgeProvenance.Source)
+	assert.Equal(t, "operator", db.saved.AgeProvenance.EditedBy)
+	assert.Equal(t, store.SourceClient, db.saved.NationalityProvenance.Source)
+	assert.Nil(t, db.saved.GenderProvenance)
+
+	//Invalid provided values result in 400
+	for _, req := range []addRequest{
+		{Name: "Ivan", Age: ptr(0)},
+		{Name: "Ivan", Gender: ptr("unknown")},
+		{Name: "Ivan", Nationality: ptr("Ukraine")},
+	} {
+		body, err := json.Marshal(req)
+		assert.NoError(t, err)
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 but got %d", resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+}
+
+// updatingStore remembers the last updated person
+type updatingStore struct {
+	store.MockStore
+	updated *store.Person
+}
+
+func (s *updatingStore) UpdatePerson(ctx context.Context, person *store.Person) error {
+	s.updated = person
+	return nil
+}
+
+func TestUpdateHandlerProvenance(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	db := &updatingStore{}
+	server := httptest.NewServer(http.HandlerFunc(New(logger.Sugar(), db, enrich.NewMockEnricher(), Options{}).updateHandler))
+	t.Cleanup(server.Close)
+
+	body, err := json.Marshal(updateRequest{ID: 1, Name: "Ivan", Age: ptr(47)})
+	assert.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPatch, server.URL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("X-User", "operator")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Edited values get the update as their provenance and are marked as provided
+	assert.Equal(t, string(enrich.StatusProvided), db.updated.AgeStatus)
+	assert.Empty(t, db.updated.GenderStatus)
+	assert.Equal(t, store.SourceUpdate, db.updated.AgeProvenance.Source)
+	assert.Equal(t, "operator", db.updated.AgeProvenance.EditedBy)
+	assert.WithinDuration(t, time.Now(), db.updated.AgeProvenance.FetchedAt, time.Minute)
+
+	//Nationality is normalized the same way as the /get filter
+	body, err = json.Marshal(updateRequest{ID: 1, Name: "Ivan", Nationality: ptr(" ru")})
+	assert.NoError(t, err)
+	req, err = http.NewRequest(http.MethodPatch, server.URL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, ptr("RU"), db.updated.Nationality)
+
+	//Invalid values are rejected instead of being saved as provided
+	db.updated = nil
+	for _, request := range []updateRequest{
+		{ID: 1, Name: "Ivan", Gender: ptr("banana")},
+		{ID: 1, Name: "Ivan", Age: ptr(-5)},
+		{ID: 1, Name: "Ivan", Nationality: ptr("russian")},
+		{ID: 1, Name: " "},
+	} {
+		body, err := json.Marshal(request)
+		assert.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPatch, server.URL, bytes.NewReader(body))
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 but got %d", resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+	assert.Nil(t, db.updated)
+}
+
+func TestProvenanceHandler(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(New(logger.Sugar(), store.NewMockStore(), enrich.NewMockEnricher(), Options{}).provenanceHandler))
+	t.Cleanup(server.Close)
+
+	//Values are returned with their provenance
+	resp, err := http.Get(server.URL + "?id=1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	var response provenanceResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, 1, response.ID)
+	assert.Equal(t, float64(30), response.Age.Value)
+	assert.Equal(t, "ok", response.Age.Status)
+	assert.Equal(t, "agify", response.Age.Provenance.Source)
+	assert.Equal(t, 1000, response.Age.Provenance.SampleCount)
+	assert.Equal(t, 0.99, response.Gender.Provenance.Probability)
+	assert.Equal(t, store.SourceUpdate, response.Nationality.Provenance.Source)
+	assert.Equal(t, "operator", response.Nationality.Provenance.EditedBy)
+
+	//Invalid id results in 400
+	resp, err = http.Get(server.URL + "?id=a")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Only GET is allowed
+	resp, err = http.Post(server.URL+"?id=1", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, fmt.Sprintf("expected 405 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+
+	//Unknown person results in 404
+	resp, err = http.Get(server.URL + "?id=2")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, fmt.Sprintf("expected 404 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestPersonHandler(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	//Route the requests the same way as Run does
+	mux := http.NewServeMux()
+	mux.HandleFunc("/people/{id}", New(logger.Sugar(), store.NewMockStore(), enrich.NewMockEnricher(), Options{}).personHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	//Existing person is returned
+	resp, err := http.Get(server.URL + "/people/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	var person store.Person
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&person))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, 1, person.ID)
+	assert.Equal(t, "Ivan", person.Name)
+	assert.Equal(t, ptr(30), person.Age)
+
+	//Unknown person results in 404, invalid id in 400 and other methods in 405
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/people/2", http.StatusNotFound},
+		{http.MethodGet, "/people/abc", http.StatusBadRequest},
+		{http.MethodPost, "/people/1", http.StatusMethodNotAllowed},
+	} {
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, http.NoBody)
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.code, resp.StatusCode, fmt.Sprintf("expected %d for %s %s but got %d", tc.code, tc.method, tc.path, resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+}
+
+func TestParseGetParamsCountries(t *testing.T) {
+	//Nationality filters match regardless of the case of the country code
+	params, err := parseGetParams(url.Values{"limit": {"5"}, "nationality": {" ru"}, "candidate": {"ua"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("RU"), params.Nationality)
+	assert.Equal(t, ptr("UA"), params.Candidate)
 }
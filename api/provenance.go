@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dafraer/effective-mobile-task/store"
+)
+
+// provenanceResponse contains the values of enriched fields of a person and where each of them came from
+type provenanceResponse struct {
+	ID          int             `json:"id"`
+	Age         fieldProvenance `json:"age"`
+	Gender      fieldProvenance `json:"gender"`
+	Nationality fieldProvenance `json:"nationality"`
+}
+
+// fieldProvenance is the value of a field with its status and provenance, unknown values and provenance are null
+type fieldProvenance struct {
+	Value      any               `json:"value"`
+	Status     string            `json:"status"`
+	Provenance *store.Provenance `json:"provenance"`
+}
+
+// provenanceHandler returns the provenance of enriched fields of a person
+// @Summary      Get provenance of a person's fields
+// @Description  Returns the age, gender and nationality of a person with the status and provenance of each of them. Provenance tells the source of the value: the provider that answered (agify, genderize, nationalize, dictionary, slavic), client for values provided on /add or update for values edited with /update. It also contains when the value was fetched or edited, the probability and the sample size reported by the provider and the user from the X-User header who provided or edited the value. Provenance is null for values enriched before it was tracked.
+// @Tags         People
+// @ID           get-person-provenance
+// @Produce      json
+// @Param        id   query     int    true  "ID of the person" example(123)
+// @Success      200  {object}  provenanceResponse "Values, statuses and provenance of age, gender and nationality"
+// @Failure      400  {string}  string "Bad Request: 'id' query parameter is required or must be an integer."
+// @Failure      404  {string}  string "Not Found: There is no person with this ID."
+// @Failure      405  {string}  string "Method Not Allowed: The HTTP method must be GET."
+// @Failure      500  {string}  string "Internal Server Error: Failed to get the person from the database."
+// @Router       /provenance [get]
+func (s *Service) provenanceHandler(w http.ResponseWriter, r *http.Request) {
+	s.logger.Infow("Request to provenanceHandler")
+
+	//Check if the method is GET
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	//Get id from query parameters
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "id must be an integer", http.StatusBadRequest)
+		s.logger.Errorw("Error converting id to int", "error", err)
+		return
+	}
+	s.logger.Debugw("Request to provenanceHandler", "id", id)
+
+	//Get the person from the database
+	person, err := s.db.GetPerson(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err, "error getting person")
+		s.logger.Errorw("Error getting person", "error", err)
+		return
+	}
+
+	//Write the provenance as a json response
+	resp, err := json.Marshal(provenanceResponse{
+		ID:          person.ID,
+		Age:         fieldProvenance{Value: value(person.Age), Status: person.AgeStatus, Provenance: person.AgeProvenance},
+		Gender:      fieldProvenance{Value: value(person.Gender), Status: person.GenderStatus, Provenance: person.GenderProvenance},
+		Nationality: fieldProvenance{Value: value(person.Nationality), Status: person.NationalityStatus, Provenance: person.NationalityProvenance},
+	})
+	if err != nil {
+		http.Error(w, "error marshalling json", http.StatusInternalServerError)
+		s.logger.Errorw("Error marshalling json", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/store"
+)
+
+// ReenrichResult contains people that were enriched again and the cursor to the next page of them
+type ReenrichResult struct {
+	NextCursor *int          `json:"next_cursor"`
+	DryRun     bool          `json:"dry_run"`
+	People     []*Reenriched `json:"people"`
+}
+
+// Reenriched is a person that was enriched again with the fields that changed
+type Reenriched struct {
+	ID      int                    `json:"id"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// FieldChange contains the stored and the newly enriched value of a field, unknown values are null
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Reenrich enriches a page of people matching params again ignoring cached results
+// The new values and enriched_at are saved unless dryRun is set, the changes are returned either way
+func (s *Service) Reenrich(ctx context.Context, params *store.GetParams, dryRun bool) (*ReenrichResult, error) {
+	s.logger.Debugw("Reenrich called", "params", *params, "dry_run", dryRun)
+
+	//Get people to re-enrich
+	people, err := s.db.GetPeople(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReenrichResult{DryRun: dryRun, People: make([]*Reenriched, 0, len(people))}
+	if len(people) == 0 {
+		return result, nil
+	}
+	result.NextCursor = &people[len(people)-1].ID
+
+	//Enrich everyone in a single batch, values provided by the client are kept
+	//Fields no provider has an answer for are saved as failed instead of failing the whole page
+	inputs := make([]enrich.Input, len(people))
+	for i, person := range people {
+		inputs[i] = enrichInput(person)
+	}
+	enriched, err := s.enricher.EnrichPeople(enrich.AllowUnresolved(enrich.SkipCache(ctx)), inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	//Compare and save the new values, people deleted during the run are skipped
+	for i, person := range people {
+		updated := *person
+		setEnrichment(&updated, enriched[i])
+		if !dryRun {
+			err := s.db.CompleteEnrichment(ctx, &updated)
+			if errors.Is(err, store.ErrNotFound) {
+				s.logger.Warnw("Re-enriched person no longer exists", "id", person.ID)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.People = append(result.People, &Reenriched{ID: person.ID, Changes: enrichmentChanges(person, &updated)})
+	}
+	s.logger.Infow("People re-enriched", "count", len(result.People), "dry_run", dryRun)
+	return result, nil
+}
+
+// enrichmentChanges returns enriched fields that differ between the stored and the re-enriched person
+func enrichmentChanges(old, new *store.Person) map[string]FieldChange {
+	fields := []struct {
+		name     string
+		old, new any
+	}{
+		{"age", value(old.Age), value(new.Age)},
+		{"gender", value(old.Gender), value(new.Gender)},
+		{"nationality", value(old.Nationality), value(new.Nationality)},
+		{"country_hint", old.CountryHint, new.CountryHint},
+		{"age_status", old.AgeStatus, new.AgeStatus},
+		{"gender_status", old.GenderStatus, new.GenderStatus},
+		{"nationality_status", old.NationalityStatus, new.NationalityStatus},
+	}
+	changes := make(map[string]FieldChange)
+	for _, f := range fields {
+		if f.old != f.new {
+			changes[f.name] = FieldChange{Old: f.old, New: f.new}
+		}
+	}
+	return changes
+}
+
+// value dereferences p, nil pointer is returned as untyped nil so that it compares equal to other unknown values
+func value[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// reenrichHandler enriches selected people again
+// @Summary      Re-enrich people
+// @Description  Runs the enrichment again for a page of people selected by IDs, by the same filters as /get, by the age of their enrichment or by failed fields. Cached enrichment results are ignored. Fields no provider has an answer for are saved with the status failed, people deleted during the run are left out. The new values are saved and enriched_at is updated unless dry_run is set, the changed fields of each person are returned either way.
+// @Tags         People
+// @ID           reenrich-people
+// @Produce      json
+// @Param        limit       query     int    true   "Number of people to re-enrich" minimum(1) maximum(1000) example(10)
+// @Param        cursor      query     int    false  "Cursor for pagination. Defaults to 0." minimum(0) example(0)
+// @Param        ids         query     string false  "Comma separated IDs of the people to re-enrich" example(1,2,3)
+// @Param        older_than_days query int false "Only people enriched more than this number of days ago or never enriched" minimum(0) example(30)
+// @Param        failed      query     bool   false  "Only people with a failed field or dead-lettered enrichment" example(true)
+// @Param        dry_run     query     bool   false  "Return the changes without saving them" example(true)
+// @Param        name        query     string false  "Filter by name, normalized like the stored names" example(Ivan)
+// @Param        surname     query     string false  "Filter by surname, normalized like the stored names" example(Ivanov)
+// @Param        patronymic  query     string false  "Filter by patronymic, normalized like the stored names" example(Ivanovich)
+// @Param        age         query     int    false  "Filter by exact age" minimum(1) example(30)
+// @Param        gender      query     string false  "Filter by gender" example(male)
+// @Param        nationality query     string false  "Filter by nationality code" example(UA)
+// @Param        enrichment_status query string false "Filter by asynchronous enrichment status" Enums(pending, done, dead) example(dead)
+// @Success      200         {object}  ReenrichResult "Changed fields of each re-enriched person and the cursor for the next page"
+// @Failure      400         {string}  string      "Bad Request: Invalid query parameter value or format."
+// @Failure      405         {string}  string      "Method Not Allowed: The HTTP method must be POST."
+// @Failure      429         {string}  string      "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header."
+// @Failure      500         {string}  string      "Internal Server Error: Failed to enrich people or to read or save them in the database."
+// @Failure      502         {string}  string      "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name."
+// @Failure      503         {string}  string      "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures."
+// @Router       /reenrich [post]
+func (s *Service) reenrichHandler(w http.ResponseWriter, r *http.Request) {
+	s.logger.Infow("Request to reenrichHandler")
+
+	//Check if the method is POST
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	//Parse query parameters
+	params := r.URL.Query()
+	s.logger.Debugw("Request to reenrichHandler", "query values", params)
+	storeParams, err := parseGetParams(params)
+	if err == nil {
+		err = parseReenrichParams(params, storeParams)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.logger.Errorw("Error parsing query parameters", "error", err)
+		return
+	}
+	dryRun := false
+	if dryRunStr := params.Get("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "dry_run must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	//Re-enrich people
+	result, err := s.Reenrich(r.Context(), storeParams, dryRun)
+	if err != nil {
+		s.writeError(w, err, "error re-enriching people")
+		s.logger.Errorw("Error re-enriching people", "error", err)
+		return
+	}
+
+	//Write the changes as a json response
+	resp, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, "error marshalling json", http.StatusInternalServerError)
+		s.logger.Errorw("Error marshalling json", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// parseReenrichParams parses the filters that select people for re-enrichment into storeParams
+func parseReenrichParams(params url.Values, storeParams *store.GetParams) error {
+	//Parse IDs
+	if idsStr := params.Get("ids"); idsStr != "" {
+		for _, idStr := range strings.Split(idsStr, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return errors.New("ids must be a comma separated list of integers")
+			}
+			storeParams.IDs = append(storeParams.IDs, id)
+		}
+	}
+
+	//Parse age of the enrichment
+	if daysStr := params.Get("older_than_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			return errors.New("older_than_days must be a non-negative integer")
+		}
+		before := time.Now().AddDate(0, 0, -days)
+		storeParams.EnrichedBefore = &before
+	}
+
+	//Parse failed filter
+	if failedStr := params.Get("failed"); failedStr != "" {
+		failed, err := strconv.ParseBool(failedStr)
+		if err != nil {
+			return errors.New("failed must be a boolean")
+		}
+		storeParams.Failed = &failed
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/store"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// reenrichStore remembers the params of the last query and the people saved after re-enrichment
+// If deleted is set, people are deleted before they are saved
+type reenrichStore struct {
+	store.MockStore
+	params    *store.GetParams
+	completed []*store.Person
+	deleted   bool
+}
+
+func (s *reenrichStore) GetPeople(ctx context.Context, params *store.GetParams) ([]*store.Person, error) {
+	s.params = params
+	return s.MockStore.GetPeople(ctx, params)
+}
+
+func (s *reenrichStore) CompleteEnrichment(ctx context.Context, person *store.Person) error {
+	if s.deleted {
+		return store.ErrNotFound
+	}
+	s.completed = append(s.completed, person)
+	return nil
+}
+
+// noAnswer is a provider that does not know any name
+type noAnswer struct{}
+
+func (noAnswer) Age(ctx context.Context, in enrich.Input) (*enrich.AgeResponse, error) {
+	return nil, enrich.ErrNoResult
+}
+
+func (noAnswer) Gender(ctx context.Context, in enrich.Input) (*enrich.GenderResponse, error) {
+	return nil, enrich.ErrNoResult
+}
+
+func (noAnswer) Nationality(ctx context.Context, in enrich.Input) (*enrich.NationalityResponse, error) {
+	return nil, enrich.ErrNoResult
+}
+
+func TestReenrichHandler(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	db := &reenrichStore{}
+	server := httptest.NewServer(http.HandlerFunc(New(logger.Sugar(), db, enrich.NewMockEnricher(), Options{}).reenrichHandler))
+	t.Cleanup(server.Close)
+
+	//Dry run returns the changes without saving them, nationality edited by the operator is kept
+	resp, err := http.Post(server.URL+"?limit=10&ids=1,%202&older_than_days=30&failed=true&name=иван&dry_run=true", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	var result ReenrichResult
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.NoError(t, resp.Body.Close())
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, *result.NextCursor)
+	assert.Equal(t, []*Reenriched{{ID: 1, Changes: map[string]FieldChange{
+		"age":    {Old: float64(30), New: nil},
+		"gender": {Old: "male", New: nil},
+	}}}, result.People)
+	assert.Empty(t, db.completed)
+
+	//Filters are passed to the store
+	assert.Equal(t, []int{1, 2}, db.params.IDs)
+	assert.Equal(t, "Ivan", *db.params.Name)
+	assert.True(t, *db.params.Failed)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), *db.params.EnrichedBefore, time.Minute)
+
+	//Without dry run the new values are saved
+	resp, err = http.Post(server.URL+"?limit=10", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+	assert.Len(t, db.completed, 1)
+	assert.Nil(t, db.completed[0].Age)
+	assert.Equal(t, "russian", *db.completed[0].Nationality)
+	assert.Equal(t, store.SourceUpdate, db.completed[0].NationalityProvenance.Source)
+	assert.NotNil(t, db.completed[0].EnrichedAt)
+
+	//Invalid filters result in 400
+	for _, query := range []string{"?limit=10&ids=1,a", "?limit=10&older_than_days=-1", "?limit=10&failed=maybe", "?limit=10&dry_run=maybe", "?ids=1"} {
+		resp, err = http.Post(server.URL+query, "", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 for %s but got %d", query, resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	//Only POST is allowed
+	resp, err = http.Get(server.URL + "?limit=10")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, fmt.Sprintf("expected 405 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestReenrichUnresolved(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	enricher := enrich.New(logger.Sugar(), enrich.Providers{
+		Age:         []enrich.AgeProvider{noAnswer{}},
+		Gender:      []enrich.GenderProvider{noAnswer{}},
+		Nationality: []enrich.NationalityProvider{noAnswer{}},
+	}, enrich.Options{})
+
+	//People no provider knows are saved with failed fields instead of failing the page
+	db := &reenrichStore{}
+	result, err := New(logger.Sugar(), db, enricher, Options{}).Reenrich(context.Background(), &store.GetParams{Limit: 10}, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.People, 1)
+	assert.Len(t, db.completed, 1)
+	assert.Equal(t, string(enrich.StatusFailed), db.completed[0].AgeStatus)
+	assert.Nil(t, db.completed[0].Age)
+
+	//People deleted during the run are not reported as re-enriched
+	db = &reenrichStore{deleted: true}
+	result, err = New(logger.Sugar(), db, enricher, Options{}).Reenrich(context.Background(), &store.GetParams{Limit: 10}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, result.People)
+}
+
+// editedStore keeps a single person, age and its status and provenance are updated only if the age changes as in the database
+type editedStore struct {
+	store.MockStore
+	person *store.Person
+}
+
+func (s *editedStore) GetPeople(ctx context.Context, params *store.GetParams) ([]*store.Person, error) {
+	person := *s.person
+	return []*store.Person{&person}, nil
+}
+
+func (s *editedStore) UpdatePerson(ctx context.Context, person *store.Person) error {
+	if value(s.person.Age) != value(person.Age) {
+		s.person.Age, s.person.AgeStatus, s.person.AgeProvenance = person.Age, person.AgeStatus, person.AgeProvenance
+	}
+	return nil
+}
+
+func (s *editedStore) CompleteEnrichment(ctx context.Context, person *store.Person) error {
+	s.person = person
+	return nil
+}
+
+// fixedAge is a provider that answers with the same age for every name
+type fixedAge int
+
+func (a fixedAge) Age(ctx context.Context, in enrich.Input) (*enrich.AgeResponse, error) {
+	return &enrich.AgeResponse{Count: 100, Name: in.Name, Age: int(a)}, nil
+}
+
+func TestReenrichKeepsEditedFields(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	people, err := store.NewMockStore().GetPeople(context.Background(), &store.GetParams{Limit: 1})
+	assert.NoError(t, err)
+	db := &editedStore{person: people[0]}
+	enricher := enrich.New(logger.Sugar(), enrich.Providers{Age: []enrich.AgeProvider{fixedAge(99)}}, enrich.Options{})
+	service := New(logger.Sugar(), db, enricher, Options{})
+
+	//Enriched age is replaced on re-enrichment
+	_, err = service.Reenrich(context.Background(), &store.GetParams{Limit: 10}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 99, *db.person.Age)
+
+	//Age edited with /update is kept
+	server := httptest.NewServer(http.HandlerFunc(service.updateHandler))
+	t.Cleanup(server.Close)
+	body, err := json.Marshal(updateRequest{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: ptr(47)})
+	assert.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPatch, server.URL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, fmt.Sprintf("expected 200 but got %d", resp.StatusCode))
+	assert.NoError(t, resp.Body.Close())
+	result, err := service.Reenrich(context.Background(), &store.GetParams{Limit: 10}, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, result.People[0].Changes, "age")
+	assert.Equal(t, 47, *db.person.Age)
+	assert.Equal(t, string(enrich.StatusProvided), db.person.AgeStatus)
+	assert.Equal(t, store.SourceUpdate, db.person.AgeProvenance.Source)
+}
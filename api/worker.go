@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/errkind"
+	"github.com/dafraer/effective-mobile-task/store"
+)
+
+// runWorkers starts workers that enrich pending people until ctx is done
+// The returned group is done when all workers have stopped
+func (s *Service) runWorkers(ctx context.Context) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := range s.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runWorker(ctx, i)
+		}()
+	}
+	s.logger.Infow("Enrichment workers started", "workers", s.opts.Workers)
+	return &wg
+}
+
+// runWorker claims pending people one by one and waits for PollInterval when there are none
+func (s *Service) runWorker(ctx context.Context, worker int) {
+	for {
+		people, err := s.db.ClaimPending(ctx, 1, s.opts.Lease)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Errorw("Error claiming pending people", "worker", worker, "error", err)
+		}
+		for _, person := range people {
+			s.enrichPending(ctx, worker, person)
+		}
+		if len(people) > 0 {
+			continue
+		}
+
+		//Wait for new people
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// enrichPending enriches the claimed person and saves the result or schedules a retry
+func (s *Service) enrichPending(ctx context.Context, worker int, person *store.Person) {
+	s.logger.Debugw("Enriching pending person", "worker", worker, "id", person.ID, "attempt", person.EnrichmentAttempts)
+
+	p, err := s.enricher.EnrichPerson(ctx, enrichInput(person))
+	if err != nil {
+		//The service is shutting down, the person is claimed again after the lease expires
+		if ctx.Err() != nil {
+			return
+		}
+		retryAt := s.retryAt(person.EnrichmentAttempts, err)
+		if retryAt == nil {
+			s.logger.Errorw("Giving up enriching person", "worker", worker, "id", person.ID, "attempts", person.EnrichmentAttempts, "error", err)
+		} else {
+			s.logger.Warnw("Error enriching person, will retry", "worker", worker, "id", person.ID, "retry_at", *retryAt, "error", err)
+		}
+		err := s.db.FailEnrichment(ctx, person.ID, err.Error(), retryAt)
+		if errors.Is(err, store.ErrNotFound) {
+			s.logger.Warnw("Pending person no longer exists", "worker", worker, "id", person.ID)
+		} else if err != nil {
+			s.logger.Errorw("Error saving failed enrichment", "worker", worker, "id", person.ID, "error", err)
+		}
+		return
+	}
+
+	//The person may have been deleted while being enriched
+	setEnrichment(person, p)
+	err = s.db.CompleteEnrichment(ctx, person)
+	if errors.Is(err, store.ErrNotFound) {
+		s.logger.Warnw("Pending person no longer exists", "worker", worker, "id", person.ID)
+		return
+	}
+	if err != nil {
+		s.logger.Errorw("Error saving enriched person", "worker", worker, "id", person.ID, "error", err)
+		return
+	}
+	s.logger.Debugw("Enriched pending person", "worker", worker, "id", person.ID)
+}
+
+// retryAt returns when the failed attempt should be retried or nil if the person should be dead-lettered
+// Backoff doubles after each attempt, but the retry is never scheduled before the provider quota resets or its circuit closes
+// Invalid inputs are dead-lettered immediately since retrying them cannot succeed
+func (s *Service) retryAt(attempts int, err error) *time.Time {
+	if attempts >= s.opts.MaxAttempts || errors.Is(err, errkind.ErrValidation) {
+		return nil
+	}
+	backoff := s.opts.Backoff
+	for i := 1; i < attempts && backoff < s.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	retryAt := time.Now().Add(min(backoff, s.opts.MaxBackoff))
+
+	var rateLimitErr *enrich.RateLimitError
+	var circuitErr *enrich.CircuitOpenError
+	if errors.As(err, &rateLimitErr) && rateLimitErr.Reset.After(retryAt) {
+		retryAt = rateLimitErr.Reset
+	}
+	if errors.As(err, &circuitErr) && circuitErr.Until.After(retryAt) {
+		retryAt = circuitErr.Until
+	}
+	return &retryAt
+}
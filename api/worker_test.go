@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/store"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// queueStore keeps pending people in memory and records the results of the workers
+type queueStore struct {
+	store.MockStore
+	mu        sync.Mutex
+	pending   []*store.Person
+	completed map[int]*store.Person
+	failed    map[int]*time.Time
+}
+
+func newQueueStore() *queueStore {
+	return &queueStore{completed: make(map[int]*store.Person), failed: make(map[int]*time.Time)}
+}
+
+func (s *queueStore) SavePerson(ctx context.Context, person *store.Person) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	person.ID = len(s.pending) + 1
+	s.pending = append(s.pending, person)
+	return person.ID, nil
+}
+
+func (s *queueStore) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*store.Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.EnrichmentStatus == store.EnrichmentPending && p.EnrichmentAttempts == 0 {
+			p.EnrichmentAttempts++
+			claimed := *p
+			return []*store.Person{&claimed}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *queueStore) CompleteEnrichment(ctx context.Context, person *store.Person) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed[person.ID] = person
+	return nil
+}
+
+func (s *queueStore) FailEnrichment(ctx context.Context, id int, message string, retryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[id] = retryAt
+	return nil
+}
+
+func TestAddHandlerAsync(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	db := newQueueStore()
+	service := New(logger.Sugar(), db, &echoEnricher{}, Options{Async: true, PollInterval: time.Millisecond * 10})
+	server := httptest.NewServer(http.HandlerFunc(service.addHandler))
+	t.Cleanup(server.Close)
+
+	//Person is saved as pending without waiting for the enrichment
+	body, err := json.Marshal(addRequest{Name: "иван", Surname: "Ivanov", CountryID: "ua"})
+	assert.NoError(t, err)
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, fmt.Sprintf("expected 202 but got %d", resp.StatusCode))
+	var response addResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, addResponse{ID: 1, EnrichmentStatus: "pending"}, response)
+	assert.Equal(t, "Ivan", db.pending[0].Name)
+	assert.Equal(t, "иван", db.pending[0].NameOriginal)
+	assert.Equal(t, "UA", db.pending[0].CountryHint)
+
+	//Person without a name is rejected before saving
+	for _, name := range []string{"", "   "} {
+		body, err := json.Marshal(addRequest{Name: name})
+		assert.NoError(t, err)
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, fmt.Sprintf("expected 400 but got %d", resp.StatusCode))
+		assert.NoError(t, resp.Body.Close())
+	}
+	assert.Len(t, db.pending, 1)
+
+	//Workers enrich the pending person and stop together with the service
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := service.runWorkers(ctx)
+	assert.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		return db.completed[1] != nil
+	}, time.Second, time.Millisecond*10)
+	cancel()
+	workers.Wait()
+	assert.Equal(t, "Ivan", db.completed[1].Name)
+}
+
+func TestEnrichPendingRetries(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	//Failed attempt is retried with backoff
+	db := newQueueStore()
+	service := New(logger.Sugar(), db, &failingEnricher{err: fmt.Errorf("provider is down")}, Options{MaxAttempts: 3, Backoff: time.Minute})
+	service.enrichPending(context.Background(), 0, &store.Person{ID: 1, Name: "Ivan", EnrichmentAttempts: 2})
+	assert.NotNil(t, db.failed[1])
+	assert.WithinDuration(t, time.Now().Add(time.Minute*2), *db.failed[1], time.Second*5)
+
+	//Retry waits for the quota to reset
+	reset := time.Now().Add(time.Hour)
+	service = New(logger.Sugar(), db, &failingEnricher{err: &enrich.RateLimitError{Provider: "agify", Reset: reset}}, Options{MaxAttempts: 3})
+	service.enrichPending(context.Background(), 0, &store.Person{ID: 2, Name: "Ivan", EnrichmentAttempts: 1})
+	assert.Equal(t, reset, *db.failed[2])
+
+	//Person is dead-lettered after the last attempt
+	service.enrichPending(context.Background(), 0, &store.Person{ID: 3, Name: "Ivan", EnrichmentAttempts: 3})
+	assert.Contains(t, db.failed, 3)
+	assert.Nil(t, db.failed[3])
+
+	//Invalid input is dead-lettered without retries
+	invalid := fmt.Errorf("%w: name is required", enrich.ErrInvalidInput)
+	service = New(logger.Sugar(), db, &failingEnricher{err: invalid}, Options{MaxAttempts: 3})
+	service.enrichPending(context.Background(), 0, &store.Person{ID: 4, EnrichmentAttempts: 1})
+	assert.Contains(t, db.failed, 4)
+	assert.Nil(t, db.failed[4])
+}
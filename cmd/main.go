@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/dafraer/effective-mobile-task/api"
@@ -47,9 +50,6 @@ func main() {
 	godotenv.Load()
 	port := os.Getenv("PORT")
 	dbConnStr := os.Getenv("DB_URI")
-	if port == "" {
-		panic("error port not found in .env")
-	}
 	if dbConnStr == "" {
 		panic("error db_uri not found in .env")
 	}
@@ -76,16 +76,176 @@ func main() {
 	storage := store.New(db, sugar)
 	sugar.Infow("Migrations performed")
 
-	//Create enricher
-	client := &http.Client{Timeout: httpClientTimeout}
-	enricher := enrich.New(client, sugar)
+	//Configure retries of the enrichment requests
+	retry := enrich.DefaultRetryPolicy()
+	if os.Getenv("ENRICH_RETRY_MAX_ATTEMPTS") != "" {
+		retry.MaxAttempts = intEnv("ENRICH_RETRY_MAX_ATTEMPTS")
+	}
+	if os.Getenv("ENRICH_RETRY_BACKOFF") != "" {
+		retry.InitialBackoff = durationEnv("ENRICH_RETRY_BACKOFF")
+	}
+	if os.Getenv("ENRICH_RETRY_MAX_BACKOFF") != "" {
+		retry.MaxBackoff = durationEnv("ENRICH_RETRY_MAX_BACKOFF")
+	}
+
+	//Configure circuit breakers of the enrichment providers
+	breaker := enrich.DefaultBreakerOptions()
+	if os.Getenv("ENRICH_BREAKER_THRESHOLD") != "" {
+		breaker.FailureThreshold = intEnv("ENRICH_BREAKER_THRESHOLD")
+	}
+	if os.Getenv("ENRICH_BREAKER_COOLDOWN") != "" {
+		breaker.CoolDown = durationEnv("ENRICH_BREAKER_COOLDOWN")
+	}
+
+	//Use local dictionary ahead of the APIs, it is reloaded on SIGHUP
+	var providers enrich.Providers
+	if path := os.Getenv("ENRICH_DICTIONARY_PATH"); path != "" {
+		dictionary, err := enrich.NewDictionaryProvider(path, sugar)
+		if err != nil {
+			panic(err)
+		}
+		providers.Age = append(providers.Age, dictionary)
+		providers.Gender = append(providers.Gender, dictionary)
+		providers.Nationality = append(providers.Nationality, dictionary)
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := dictionary.Reload(); err != nil {
+					sugar.Errorw("Error reloading dictionary", "error", err)
+				}
+			}
+		}()
+	}
+
+	//Use the agify, genderize and nationalize APIs unless the service runs offline
+	if !boolEnv("ENRICH_OFFLINE") {
+		client := &http.Client{Timeout: httpClientTimeout}
+		provider := enrich.NewAPIProvider(client, sugar, enrich.APIOptions{
+			AgeApiUrl:          os.Getenv("AGE_API_URL"),
+			GenderApiUrl:       os.Getenv("GENDER_API_URL"),
+			NationalityApiUrl:  os.Getenv("NATIONALITY_API_URL"),
+			ApiKey:             os.Getenv("ENRICH_API_KEY"),
+			AgeTimeout:         durationEnv("AGE_API_TIMEOUT"),
+			GenderTimeout:      durationEnv("GENDER_API_TIMEOUT"),
+			NationalityTimeout: durationEnv("NATIONALITY_API_TIMEOUT"),
+			Retry:              retry,
+			Breaker:            breaker,
+			MaxResponseSize:    int64(intEnv("ENRICH_MAX_RESPONSE_SIZE")),
+		})
+		providers.Age = append(providers.Age, provider)
+		providers.Gender = append(providers.Gender, provider)
+		providers.Nationality = append(providers.Nationality, provider)
+	}
+
+	//Infer gender of Slavic names from patronymic and surname before or instead of other providers
+	switch mode := os.Getenv("ENRICH_SLAVIC_GENDER"); mode {
+	case "":
+	case "first":
+		providers.Gender = append([]enrich.GenderProvider{enrich.NewSlavicGenderProvider(sugar)}, providers.Gender...)
+	case "only":
+		providers.Gender = []enrich.GenderProvider{enrich.NewSlavicGenderProvider(sugar)}
+	default:
+		panic(fmt.Errorf("invalid ENRICH_SLAVIC_GENDER %q, expected first or only", mode))
+	}
+	enricher := enrich.New(sugar, providers, enrich.Options{
+		MinAgeSampleCount:         intEnv("ENRICH_MIN_AGE_SAMPLES"),
+		MinGenderProbability:      floatEnv("ENRICH_MIN_GENDER_PROBABILITY"),
+		MinNationalityProbability: floatEnv("ENRICH_MIN_NATIONALITY_PROBABILITY"),
+		LocalizeByNationality:     boolEnv("ENRICH_LOCALIZE_BY_NATIONALITY"),
+		AllowPartial:              boolEnv("ENRICH_ALLOW_PARTIAL"),
+	})
+
+	//Wrap enricher with cache, results are persisted in the database if ENRICH_CACHE_PERSIST is set
+	//Slavic gender depends on the surname and patronymic, so they are a part of the cache key
+	cacheOpts := enrich.CacheOptions{
+		Size:         intEnv("ENRICH_CACHE_SIZE"),
+		TTL:          durationEnv("ENRICH_CACHE_TTL"),
+		KeyBySurname: os.Getenv("ENRICH_SLAVIC_GENDER") != "",
+	}
+	if boolEnv("ENRICH_CACHE_PERSIST") {
+		cacheOpts.Store = store.NewEnrichmentCache(db, sugar)
+	}
+	enricher = enrich.NewCachingEnricher(enricher, sugar, cacheOpts)
 
 	//Create and run the service
-	service := api.New(sugar, storage, enricher)
+	service := api.New(sugar, storage, enricher, api.Options{
+		Async:        boolEnv("ENRICH_ASYNC"),
+		Workers:      intEnv("ENRICH_QUEUE_WORKERS"),
+		PollInterval: durationEnv("ENRICH_QUEUE_POLL_INTERVAL"),
+		Lease:        durationEnv("ENRICH_QUEUE_LEASE"),
+		MaxAttempts:  intEnv("ENRICH_QUEUE_MAX_ATTEMPTS"),
+		Backoff:      durationEnv("ENRICH_QUEUE_BACKOFF"),
+		MaxBackoff:   durationEnv("ENRICH_QUEUE_MAX_BACKOFF"),
+	})
 	sugar.Infow("New service created")
 
+	//Run the re-enrichment job instead of the server, e.g. main reenrich -older-than-days 30 -dry-run
+	if len(os.Args) > 1 && os.Args[1] == "reenrich" {
+		if err := reenrich(context.Background(), service, sugar, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if port == "" {
+		panic("error port not found in .env")
+	}
 	if err := service.Run(context.Background(), port); err != nil {
 		panic(err)
 	}
 	sugar.Infow("Service stopped")
 }
+
+// durationEnv parses environment variable as time.Duration and returns 0 if it is not set
+func durationEnv(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Errorf("error parsing %s: %v", key, err))
+	}
+	return d
+}
+
+// intEnv parses environment variable as int and returns 0 if it is not set
+func intEnv(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Errorf("error parsing %s: %v", key, err))
+	}
+	return n
+}
+
+// floatEnv parses environment variable as float64 and returns 0 if it is not set
+func floatEnv(key string) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		panic(fmt.Errorf("error parsing %s: %v", key, err))
+	}
+	return f
+}
+
+// boolEnv parses environment variable as bool and returns false if it is not set
+func boolEnv(key string) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		panic(fmt.Errorf("error parsing %s: %v", key, err))
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/api"
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/store"
+	"go.uber.org/zap"
+)
+
+// reenrich enriches people selected by the command line flags again page by page
+// Changes of each person are written to stdout as json lines
+func reenrich(ctx context.Context, service *api.Service, logger *zap.SugaredLogger, args []string) error {
+	flags := flag.NewFlagSet("reenrich", flag.ContinueOnError)
+	ids := flags.String("ids", "", "comma separated IDs of the people to re-enrich")
+	olderThanDays := flags.Int("older-than-days", -1, "only people enriched more than this number of days ago or never enriched")
+	failed := flags.Bool("failed", false, "only people with a failed field or dead-lettered enrichment")
+	name := flags.String("name", "", "filter by name")
+	surname := flags.String("surname", "", "filter by surname")
+	patronymic := flags.String("patronymic", "", "filter by patronymic")
+	age := flags.Int("age", 0, "filter by exact age")
+	gender := flags.String("gender", "", "filter by gender")
+	nationality := flags.String("nationality", "", "filter by nationality code")
+	batch := flags.Int("batch", 100, "number of people re-enriched at once")
+	dryRun := flags.Bool("dry-run", false, "print the changes without saving them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batch < 1 {
+		return fmt.Errorf("batch must be a positive integer")
+	}
+
+	//Build the filter the same way as /reenrich does
+	params := store.NewParams(*batch, 0, *age, enrich.NormalizeName(*name), enrich.NormalizeName(*surname), enrich.NormalizeName(*patronymic), *gender, enrich.NormalizeCountryID(*nationality))
+	if *ids != "" {
+		for _, idStr := range strings.Split(*ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return fmt.Errorf("error parsing ids: %v", err)
+			}
+			params.IDs = append(params.IDs, id)
+		}
+	}
+	if *olderThanDays >= 0 {
+		before := time.Now().AddDate(0, 0, -*olderThanDays)
+		params.EnrichedBefore = &before
+	}
+	if *failed {
+		params.Failed = failed
+	}
+
+	//Re-enrich every page and print the changes
+	encoder := json.NewEncoder(os.Stdout)
+	total := 0
+	for {
+		result, err := service.Reenrich(ctx, params, *dryRun)
+		if err != nil {
+			return err
+		}
+		for _, person := range result.People {
+			if err := encoder.Encode(person); err != nil {
+				return err
+			}
+		}
+		total += len(result.People)
+		if result.NextCursor == nil {
+			break
+		}
+		params.Cursor = result.NextCursor
+	}
+	logger.Infow("Re-enrichment finished", "count", total, "dry_run", *dryRun)
+	return nil
+}
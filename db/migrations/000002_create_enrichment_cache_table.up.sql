@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+		key TEXT PRIMARY KEY,
+		value JSONB NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+);
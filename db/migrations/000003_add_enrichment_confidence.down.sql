@@ -0,0 +1,4 @@
+ALTER TABLE people
+		DROP COLUMN IF EXISTS age_sample_count,
+		DROP COLUMN IF EXISTS gender_probability,
+		DROP COLUMN IF EXISTS nationality_probability;
@@ -0,0 +1,4 @@
+ALTER TABLE people
+		ADD COLUMN IF NOT EXISTS age_sample_count INT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS gender_probability DOUBLE PRECISION NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS nationality_probability DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS nationality_candidates JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+UPDATE people SET age = NULL WHERE age = 0;
+UPDATE people SET gender = NULL WHERE gender = '';
+UPDATE people SET nationality = NULL WHERE nationality = '';
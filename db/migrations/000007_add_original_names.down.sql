@@ -0,0 +1,3 @@
+ALTER TABLE people DROP COLUMN IF EXISTS name_original;
+ALTER TABLE people DROP COLUMN IF EXISTS surname_original;
+ALTER TABLE people DROP COLUMN IF EXISTS patronymic_original;
@@ -0,0 +1,4 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS name_original TEXT NOT NULL DEFAULT '';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS surname_original TEXT NOT NULL DEFAULT '';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS patronymic_original TEXT NOT NULL DEFAULT '';
+UPDATE people SET name_original = COALESCE(name, ''), surname_original = COALESCE(surname, ''), patronymic_original = COALESCE(patronymic, '');
@@ -0,0 +1,3 @@
+ALTER TABLE people DROP COLUMN IF EXISTS age_status;
+ALTER TABLE people DROP COLUMN IF EXISTS gender_status;
+ALTER TABLE people DROP COLUMN IF EXISTS nationality_status;
@@ -0,0 +1,3 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS age_status TEXT NOT NULL DEFAULT 'ok';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS gender_status TEXT NOT NULL DEFAULT 'ok';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS nationality_status TEXT NOT NULL DEFAULT 'ok';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS people_pending_idx;
+ALTER TABLE people DROP COLUMN IF EXISTS enrichment_status;
+ALTER TABLE people DROP COLUMN IF EXISTS enrichment_attempts;
+ALTER TABLE people DROP COLUMN IF EXISTS enrichment_error;
+ALTER TABLE people DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,5 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS enrichment_status TEXT NOT NULL DEFAULT 'done';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS enrichment_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS enrichment_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE people ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS people_pending_idx ON people (next_attempt_at) WHERE enrichment_status = 'pending';
@@ -0,0 +1,3 @@
+ALTER TABLE people DROP COLUMN IF EXISTS age_provenance;
+ALTER TABLE people DROP COLUMN IF EXISTS gender_provenance;
+ALTER TABLE people DROP COLUMN IF EXISTS nationality_provenance;
@@ -0,0 +1,3 @@
+ALTER TABLE people ADD COLUMN IF NOT EXISTS age_provenance JSONB;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS gender_provenance JSONB;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS nationality_provenance JSONB;
@@ -0,0 +1,3 @@
+UPDATE people SET age_status = '' WHERE age_status = 'pending';
+UPDATE people SET gender_status = '' WHERE gender_status = 'pending';
+UPDATE people SET nationality_status = '' WHERE nationality_status = 'pending';
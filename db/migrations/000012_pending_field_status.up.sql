@@ -0,0 +1,3 @@
+UPDATE people SET age_status = CASE WHEN enrichment_status = 'dead' THEN 'failed' ELSE 'pending' END WHERE age_status = '' AND enrichment_status <> 'done';
+UPDATE people SET gender_status = CASE WHEN enrichment_status = 'dead' THEN 'failed' ELSE 'pending' END WHERE gender_status = '' AND enrichment_status <> 'done';
+UPDATE people SET nationality_status = CASE WHEN enrichment_status = 'dead' THEN 'failed' ELSE 'pending' END WHERE nationality_status = '' AND enrichment_status <> 'done';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS enrichment_cache_expires_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS enrichment_cache_expires_at_idx ON enrichment_cache (expires_at);
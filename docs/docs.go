@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/add": {
             "post": {
-                "description": "Takes basic person details (name, surname, patronymic(optional)), enriches them with additional data (age, gender, nationality), saves the complete record to the database, and returns the newly generated ID.",
+                "description": "Takes basic person details (name, surname, patronymic(optional), country_id(optional)) and optionally the known age, gender and nationality, which are saved with the status provided instead of being looked up, enriches them with additional data (age, gender, nationality), saves the record to the database, and returns the newly generated ID with the enrichment status of each field (ok, failed or skipped). If partial enrichment is enabled, the person is saved even if some fields failed to resolve. In asynchronous mode the person is saved with enrichment_status pending and 202 is returned immediately, the enrichment result is visible via /get. If country_id is set, age and gender are localized to that country. Names are trimmed, title-cased and transliterated from Cyrillic to Latin, the submitted spelling is saved as name_original, surname_original and patronymic_original.",
                 "consumes": [
                     "application/json"
                 ],
@@ -31,24 +31,37 @@ const docTemplate = `{
                 "operationId": "add-person",
                 "parameters": [
                     {
-                        "description": "Basic person details (name, surname, patronymic(optional)) to add and enrich.",
+                        "description": "Basic person details (name, surname, patronymic(optional)) to add and enrich, with optional known age, gender and nationality.",
                         "name": "person",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "$ref": "#/definitions/api.addRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "example": "operator@example.com",
+                        "description": "User adding the person, saved in the provenance of provided age, gender and nationality",
+                        "name": "X-User",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully added person, returns the new person's ID.\" example(12345) // Assuming ID is an integer",
+                        "description": "Successfully added person, returns the new person's ID and the enrichment status of each field.",
+                        "schema": {
+                            "$ref": "#/definitions/api.addResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Person is saved as pending and will be enriched in the background, returns the new person's ID.",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/api.addResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request: Error decoding JSON request body.",
+                        "description": "Bad Request: Error decoding JSON request body, missing name, invalid country_id or invalid provided age, gender or nationality.",
                         "schema": {
                             "type": "string"
                         }
@@ -59,11 +72,35 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Conflict: The person conflicts with data saved concurrently.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error: Failed to enrich person data or save the person to the database.",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures.",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
@@ -102,6 +139,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found: There is no person with this ID.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "405": {
                         "description": "Method Not Allowed: The HTTP method used is not DELETE.",
                         "schema": {
@@ -117,6 +160,200 @@ const docTemplate = `{
                 }
             }
         },
+        "/enrich": {
+            "get": {
+                "description": "Enriches person details (name, surname, patronymic(optional), country_id(optional)) with age, gender and nationality together with their probabilities and returns the result without saving it. Accepts the details either as query parameters (GET) or as the same JSON body as /add (POST). Results are cached, so adding the same person with /add afterwards does not call the providers again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Preview enrichment of a person",
+                "operationId": "enrich-person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Ivan",
+                        "description": "Name of the person, required for GET",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanov",
+                        "description": "Surname of the person",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanovich",
+                        "description": "Patronymic of the person",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UA",
+                        "description": "Two-letter country code used to localize age and gender",
+                        "name": "country_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Person details for POST, the same as for /add.",
+                        "name": "person",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.addRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Enriched person with the probabilities and the status of each field.",
+                        "schema": {
+                            "$ref": "#/definitions/enrich.Person"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Error decoding JSON request body, missing name or invalid country_id.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed: The HTTP method must be GET or POST.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: Failed to enrich person data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Enriches person details (name, surname, patronymic(optional), country_id(optional)) with age, gender and nationality together with their probabilities and returns the result without saving it. Accepts the details either as query parameters (GET) or as the same JSON body as /add (POST). Results are cached, so adding the same person with /add afterwards does not call the providers again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Preview enrichment of a person",
+                "operationId": "enrich-person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Ivan",
+                        "description": "Name of the person, required for GET",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanov",
+                        "description": "Surname of the person",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanovich",
+                        "description": "Patronymic of the person",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UA",
+                        "description": "Two-letter country code used to localize age and gender",
+                        "name": "country_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Person details for POST, the same as for /add.",
+                        "name": "person",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.addRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Enriched person with the probabilities and the status of each field.",
+                        "schema": {
+                            "$ref": "#/definitions/enrich.Person"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Error decoding JSON request body, missing name or invalid country_id.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed: The HTTP method must be GET or POST.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: Failed to enrich person data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/get": {
             "get": {
                 "description": "Retrieves a paginated list of people based on filter criteria provided as query parameters.",
@@ -129,15 +366,263 @@ const docTemplate = `{
                 "tags": [
                     "People"
                 ],
-                "summary": "Get a list of people",
-                "operationId": "get-people-list",
+                "summary": "Get a list of people",
+                "operationId": "get-people-list",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "description": "Number of items to return per page (must be between 1 and 100)",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 0,
+                        "description": "Cursor for pagination (indicates the starting item index). Defaults to 0.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivan",
+                        "description": "Filter by name, normalized like the stored names so Иван, ivan and IVAN all match Ivan",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanov",
+                        "description": "Filter by surname, normalized like the stored names",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Ivanovich",
+                        "description": "Filter by patronymic, normalized like the stored names",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Filter by exact age",
+                        "name": "age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "male",
+                        "description": "Filter by gender (e.g., 'male', 'female')",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UA",
+                        "description": "Filter by nationality code",
+                        "name": "nationality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "UA",
+                        "description": "Filter by country code among nationality candidates",
+                        "name": "candidate",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Only match candidate among the top N ranked candidates, requires candidate",
+                        "name": "candidate_top",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "example": 0.2,
+                        "description": "Only match candidate with probability greater than this value, requires candidate",
+                        "name": "candidate_probability",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "done",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "example": "pending",
+                        "description": "Filter by asynchronous enrichment status",
+                        "name": "enrichment_status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A paginated list of people and the cursor for the next page",
+                        "schema": {
+                            "$ref": "#/definitions/api.getResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameter value or format (e.g., non-integer limit, limit out of range, negative age/cursor).",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed: The HTTP method used is not GET.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: Failed to retrieve data from the database or failed to marshal the JSON response.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/people/{id}": {
+            "get": {
+                "description": "Retrieves a single person with all stored fields based on the ID in the path.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Get a person by ID",
+                "operationId": "get-person-by-id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID of the person",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The person",
+                        "schema": {
+                            "$ref": "#/definitions/store.Person"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: 'id' must be an integer.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: There is no person with this ID.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed: The HTTP method used is not GET.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: Failed to retrieve the person from the database or failed to marshal the JSON response.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/provenance": {
+            "get": {
+                "description": "Returns the age, gender and nationality of a person with the status and provenance of each of them. Provenance tells the source of the value: the provider that answered (agify, genderize, nationalize, dictionary, slavic), client for values provided on /add or update for values edited with /update. It also contains when the value was fetched or edited, the probability and the sample size reported by the provider and the user from the X-User header who provided or edited the value. Provenance is null for values enriched before it was tracked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Get provenance of a person's fields",
+                "operationId": "get-person-provenance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID of the person",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Values, statuses and provenance of age, gender and nationality",
+                        "schema": {
+                            "$ref": "#/definitions/api.provenanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: 'id' query parameter is required or must be an integer.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: There is no person with this ID.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed: The HTTP method must be GET.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: Failed to get the person from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reenrich": {
+            "post": {
+                "description": "Runs the enrichment again for a page of people selected by IDs, by the same filters as /get, by the age of their enrichment or by failed fields. Cached enrichment results are ignored. Fields no provider has an answer for are saved with the status failed, people deleted during the run are left out. The new values are saved and enriched_at is updated unless dry_run is set, the changed fields of each person are returned either way.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Re-enrich people",
+                "operationId": "reenrich-people",
                 "parameters": [
                     {
                         "maximum": 1000,
                         "minimum": 1,
                         "type": "integer",
                         "example": 10,
-                        "description": "Number of items to return per page (must be between 1 and 100)",
+                        "description": "Number of people to re-enrich",
                         "name": "limit",
                         "in": "query",
                         "required": true
@@ -146,28 +631,57 @@ const docTemplate = `{
                         "minimum": 0,
                         "type": "integer",
                         "example": 0,
-                        "description": "Cursor for pagination (indicates the starting item index). Defaults to 0.",
+                        "description": "Cursor for pagination. Defaults to 0.",
                         "name": "cursor",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "example": "1,2,3",
+                        "description": "Comma separated IDs of the people to re-enrich",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Only people enriched more than this number of days ago or never enriched",
+                        "name": "older_than_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Only people with a failed field or dead-lettered enrichment",
+                        "name": "failed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Return the changes without saving them",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "example": "Ivan",
-                        "description": "Filter by exact name (case-sensitive)",
+                        "description": "Filter by name, normalized like the stored names",
                         "name": "name",
                         "in": "query"
                     },
                     {
                         "type": "string",
                         "example": "Ivanov",
-                        "description": "Filter by exact surname (case-sensitive)",
+                        "description": "Filter by surname, normalized like the stored names",
                         "name": "surname",
                         "in": "query"
                     },
                     {
                         "type": "string",
                         "example": "Ivanovich",
-                        "description": "Filter by exact patronymic (case-sensitive)",
+                        "description": "Filter by patronymic, normalized like the stored names",
                         "name": "patronymic",
                         "in": "query"
                     },
@@ -182,7 +696,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "male",
-                        "description": "Filter by gender (e.g., 'male', 'female')",
+                        "description": "Filter by gender",
                         "name": "gender",
                         "in": "query"
                     },
@@ -192,29 +706,59 @@ const docTemplate = `{
                         "description": "Filter by nationality code",
                         "name": "nationality",
                         "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "done",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "example": "dead",
+                        "description": "Filter by asynchronous enrichment status",
+                        "name": "enrichment_status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "A paginated list of people and the cursor for the next page",
+                        "description": "Changed fields of each re-enriched person and the cursor for the next page",
                         "schema": {
-                            "$ref": "#/definitions/api.getResponse"
+                            "$ref": "#/definitions/api.ReenrichResult"
                         }
                     },
                     "400": {
-                        "description": "Bad Request: Invalid query parameter value or format (e.g., non-integer limit, limit out of range, negative age/cursor).",
+                        "description": "Bad Request: Invalid query parameter value or format.",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "405": {
-                        "description": "Method Not Allowed: The HTTP method used is not GET.",
+                        "description": "Method Not Allowed: The HTTP method must be POST.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests: Enrichment provider quota is exhausted, see Retry-After header.",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error: Failed to retrieve data from the database or failed to marshal the JSON response.",
+                        "description": "Internal Server Error: Failed to enrich people or to read or save them in the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway: Enrichment provider returned a response that is too large, is not json or contains invalid values, or no provider has a result for the name.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable: Enrichment provider responded with an error or is temporarily disabled after repeated failures.",
                         "schema": {
                             "type": "string"
                         }
@@ -224,7 +768,7 @@ const docTemplate = `{
         },
         "/update": {
             "put": {
-                "description": "Updates fields for an existing person based on the provided data.",
+                "description": "Updates fields for an existing person based on the provided data. Names are normalized the same way as in /add. Changed age, gender and nationality are saved with the status provided, so re-enrichment keeps them.",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,6 +789,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.updateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "example": "operator@example.com",
+                        "description": "User editing the person, saved in the provenance of changed age, gender and nationality",
+                        "name": "X-User",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -255,7 +806,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request: Error decoding JSON request body.",
+                        "description": "Bad Request: Error decoding JSON request body, missing name, invalid age, gender or nationality, or a value is out of range.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: There is no person with this ID.",
                         "schema": {
                             "type": "string"
                         }
@@ -275,7 +832,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
-                "description": "Updates fields for an existing person based on the provided data.",
+                "description": "Updates fields for an existing person based on the provided data. Names are normalized the same way as in /add. Changed age, gender and nationality are saved with the status provided, so re-enrichment keeps them.",
                 "consumes": [
                     "application/json"
                 ],
@@ -296,6 +853,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.updateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "example": "operator@example.com",
+                        "description": "User editing the person, saved in the provenance of changed age, gender and nationality",
+                        "name": "X-User",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -306,7 +870,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request: Error decoding JSON request body.",
+                        "description": "Bad Request: Error decoding JSON request body, missing name, invalid age, gender or nationality, or a value is out of range.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: There is no person with this ID.",
                         "schema": {
                             "type": "string"
                         }
@@ -328,12 +898,64 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "api.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "api.ReenrichResult": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "integer"
+                },
+                "people": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Reenriched"
+                    }
+                }
+            }
+        },
+        "api.Reenriched": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
         "api.addRequest": {
             "type": "object",
             "properties": {
+                "age": {
+                    "description": "Optional values known by the client, they are saved as provided instead of being looked up",
+                    "type": "integer"
+                },
+                "country_id": {
+                    "description": "optional ISO 3166-1 alpha-2 code used to localize age and gender",
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "nationality": {
+                    "type": "string"
+                },
                 "patronymic": {
                     "type": "string"
                 },
@@ -342,6 +964,46 @@ const docTemplate = `{
                 }
             }
         },
+        "api.addResponse": {
+            "type": "object",
+            "properties": {
+                "enrichment_status": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/api.enrichmentStatus"
+                }
+            }
+        },
+        "api.enrichmentStatus": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "nationality": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.fieldProvenance": {
+            "type": "object",
+            "properties": {
+                "provenance": {
+                    "$ref": "#/definitions/store.Provenance"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "value": {}
+            }
+        },
         "api.getResponse": {
             "type": "object",
             "properties": {
@@ -356,6 +1018,23 @@ const docTemplate = `{
                 }
             }
         },
+        "api.provenanceResponse": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "$ref": "#/definitions/api.fieldProvenance"
+                },
+                "gender": {
+                    "$ref": "#/definitions/api.fieldProvenance"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "nationality": {
+                    "$ref": "#/definitions/api.fieldProvenance"
+                }
+            }
+        },
         "api.updateRequest": {
             "type": "object",
             "properties": {
@@ -382,29 +1061,250 @@ const docTemplate = `{
                 }
             }
         },
+        "enrich.Country": {
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                }
+            }
+        },
+        "enrich.FieldStatus": {
+            "type": "string",
+            "enum": [
+                "ok",
+                "failed",
+                "skipped",
+                "provided"
+            ],
+            "x-enum-comments": {
+                "StatusFailed": "every provider failed or had no answer",
+                "StatusOK": "provider answered, the value may still be unknown if the answer was not confident",
+                "StatusProvided": "value was supplied with the input, providers were not asked",
+                "StatusSkipped": "no provider is configured for the field"
+            },
+            "x-enum-varnames": [
+                "StatusOK",
+                "StatusFailed",
+                "StatusSkipped",
+                "StatusProvided"
+            ]
+        },
+        "enrich.Person": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "age_provenance": {
+                    "description": "Where the answer for each field came from, nil if no provider answered",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/enrich.Provenance"
+                        }
+                    ]
+                },
+                "age_sample_count": {
+                    "description": "Confidence of the guesses",
+                    "type": "integer"
+                },
+                "age_status": {
+                    "description": "How the enrichment of each field went",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/enrich.FieldStatus"
+                        }
+                    ]
+                },
+                "country_hint": {
+                    "description": "Country that age and gender were localized to, empty if they were not",
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "gender_probability": {
+                    "type": "number"
+                },
+                "gender_provenance": {
+                    "$ref": "#/definitions/enrich.Provenance"
+                },
+                "gender_status": {
+                    "$ref": "#/definitions/enrich.FieldStatus"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nationality": {
+                    "type": "string"
+                },
+                "nationality_candidates": {
+                    "description": "All countries returned by the nationality provider ranked by probability",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/enrich.Country"
+                    }
+                },
+                "nationality_probability": {
+                    "type": "number"
+                },
+                "nationality_provenance": {
+                    "$ref": "#/definitions/enrich.Provenance"
+                },
+                "nationality_status": {
+                    "$ref": "#/definitions/enrich.FieldStatus"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "enrich.Provenance": {
+            "type": "object",
+            "properties": {
+                "fetched_at": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                },
+                "sample_count": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.NationalityCandidate": {
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                }
+            }
+        },
         "store.Person": {
             "type": "object",
             "properties": {
                 "age": {
                     "type": "integer"
                 },
+                "age_provenance": {
+                    "description": "Where the value of each field came from, nil if it is unknown",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Provenance"
+                        }
+                    ]
+                },
+                "age_sample_count": {
+                    "description": "Confidence of the enriched values",
+                    "type": "integer"
+                },
+                "age_status": {
+                    "description": "Enrichment status of each field: ok, failed, skipped, provided or pending until a worker enriches it",
+                    "type": "string"
+                },
+                "country_hint": {
+                    "description": "Country that age and gender were localized to, empty if they were not",
+                    "type": "string"
+                },
+                "enriched_at": {
+                    "description": "When the person was last enriched, nil if they have never been",
+                    "type": "string"
+                },
+                "enrichment_attempts": {
+                    "type": "integer"
+                },
+                "enrichment_error": {
+                    "type": "string"
+                },
+                "enrichment_status": {
+                    "description": "Asynchronous enrichment state: pending, done or dead, with the number of attempts and the last error",
+                    "type": "string"
+                },
                 "gender": {
                     "type": "string"
                 },
+                "gender_probability": {
+                    "type": "number"
+                },
+                "gender_provenance": {
+                    "$ref": "#/definitions/store.Provenance"
+                },
+                "gender_status": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "name": {
                     "type": "string"
                 },
+                "name_original": {
+                    "description": "Name as it was submitted before normalization",
+                    "type": "string"
+                },
                 "nationality": {
                     "type": "string"
                 },
+                "nationality_candidates": {
+                    "description": "Countries the person may be from ranked by probability",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.NationalityCandidate"
+                    }
+                },
+                "nationality_probability": {
+                    "type": "number"
+                },
+                "nationality_provenance": {
+                    "$ref": "#/definitions/store.Provenance"
+                },
+                "nationality_status": {
+                    "type": "string"
+                },
                 "patronymic": {
                     "type": "string"
                 },
+                "patronymic_original": {
+                    "type": "string"
+                },
                 "surname": {
                     "type": "string"
+                },
+                "surname_original": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Provenance": {
+            "type": "object",
+            "properties": {
+                "edited_by": {
+                    "type": "string"
+                },
+                "fetched_at": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                },
+                "sample_count": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
                 }
             }
         }
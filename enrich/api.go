@@ -0,0 +1,133 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultAgeApiUrl         = "https://api.agify.io/"
+	DefaultGenderApiUrl      = "https://api.genderize.io/"
+	DefaultNationalityApiUrl = "https://api.nationalize.io/"
+
+	//DefaultMaxResponseSize is enough for a batch of names, real responses are a few kilobytes at most
+	DefaultMaxResponseSize = 1 << 20
+)
+
+// APIOptions configures the agify, genderize and nationalize provider
+// Empty URLs are replaced with the public APIs and zero timeouts mean that only the client timeout applies
+// Per-provider timeouts apply to each attempt separately
+// Responses larger than MaxResponseSize bytes are rejected, zero means DefaultMaxResponseSize
+type APIOptions struct {
+	AgeApiUrl          string
+	GenderApiUrl       string
+	NationalityApiUrl  string
+	ApiKey             string
+	AgeTimeout         time.Duration
+	GenderTimeout      time.Duration
+	NationalityTimeout time.Duration
+	Retry              RetryPolicy
+	Breaker            BreakerOptions
+	MaxResponseSize    int64
+}
+
+// APIProvider gets age, gender and nationality from agify, genderize and nationalize APIs
+// It implements all provider interfaces including the batch ones
+type APIProvider struct {
+	client      *http.Client
+	logger      *zap.SugaredLogger
+	opts        APIOptions
+	age         *endpoint
+	gender      *endpoint
+	nationality *endpoint
+}
+
+// NewAPIProvider returns a provider that queries the APIs configured in opts
+func NewAPIProvider(client *http.Client, logger *zap.SugaredLogger, opts APIOptions) *APIProvider {
+	if opts.AgeApiUrl == "" {
+		opts.AgeApiUrl = DefaultAgeApiUrl
+	}
+	if opts.GenderApiUrl == "" {
+		opts.GenderApiUrl = DefaultGenderApiUrl
+	}
+	if opts.NationalityApiUrl == "" {
+		opts.NationalityApiUrl = DefaultNationalityApiUrl
+	}
+	if opts.MaxResponseSize <= 0 {
+		opts.MaxResponseSize = DefaultMaxResponseSize
+	}
+	return &APIProvider{
+		client:      client,
+		logger:      logger,
+		opts:        opts,
+		age:         newEndpoint("agify", opts.AgeApiUrl, opts.AgeTimeout, opts.Breaker, logger),
+		gender:      newEndpoint("genderize", opts.GenderApiUrl, opts.GenderTimeout, opts.Breaker, logger),
+		nationality: newEndpoint("nationalize", opts.NationalityApiUrl, opts.NationalityTimeout, opts.Breaker, logger),
+	}
+}
+
+// ProviderName returns the name of the API the field is requested from
+func (p *APIProvider) ProviderName(field string) string {
+	switch field {
+	case "age":
+		return p.age.name
+	case "gender":
+		return p.gender.name
+	default:
+		return p.nationality.name
+	}
+}
+
+// Age makes a request to the agify API and returns most probable age with the number of samples it is based on
+// The age is localized to the country if in.CountryID is not empty
+func (p *APIProvider) Age(ctx context.Context, in Input) (*AgeResponse, error) {
+	var ageResponse AgeResponse
+	if err := p.get(ctx, p.age, nameParams(in.Name, in.CountryID), &ageResponse); err != nil {
+		return nil, err
+	}
+
+	//Return the age
+	return &ageResponse, nil
+}
+
+// Gender makes a request to the genderize API and returns the most probable gender with its probability
+// The gender is localized to the country if in.CountryID is not empty
+func (p *APIProvider) Gender(ctx context.Context, in Input) (*GenderResponse, error) {
+	var genderResponse GenderResponse
+	if err := p.get(ctx, p.gender, nameParams(in.Name, in.CountryID), &genderResponse); err != nil {
+		return nil, err
+	}
+
+	//Return the gender
+	return &genderResponse, nil
+}
+
+// Nationality makes a request to the nationalize API and returns countries ranked by probability
+// It returns ErrNoResult if the API does not know any country for the name
+func (p *APIProvider) Nationality(ctx context.Context, in Input) (*NationalityResponse, error) {
+	var nationalityResponse NationalityResponse
+	if err := p.get(ctx, p.nationality, nameParams(in.Name, ""), &nationalityResponse); err != nil {
+		return nil, err
+	}
+
+	// Return the nationality
+	if len(nationalityResponse.Country) > 0 {
+		return &nationalityResponse, nil
+	}
+	return nil, fmt.Errorf("Empty response from nationalize API: %w", ErrNoResult)
+}
+
+// nameParams returns query parameters for the name and optional country hint
+func nameParams(name, countryID string) url.Values {
+	params := url.Values{}
+	params.Add("name", name)
+	if countryID != "" {
+		params.Add("country_id", countryID)
+	}
+	return params
+}
@@ -0,0 +1,284 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.uber.org/zap"
+)
+
+// maxBatchSize is the maximum number of names the APIs accept in a single request
+const maxBatchSize = 10
+
+// EnrichPeople enriches many people asking each provider about all people that are still unresolved at once
+// Providers implementing the batch interfaces are asked with a single call, the rest are asked person by person
+// The returned slice has the same order as inputs, names are normalized as in EnrichPerson
+func (e *defaultEnricher) EnrichPeople(ctx context.Context, inputs []Input) ([]*Person, error) {
+	e.logger.Debugw("EnrichPeople called", "count", len(inputs))
+	if len(inputs) == 0 {
+		return []*Person{}, nil
+	}
+	inputs = normalizeInputs(inputs)
+	for i, in := range inputs {
+		if err := validateInput(in); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	//Get nationalities of everyone whose nationality is not known
+	nationalities, err := resolveBatch(ctx, e.logger, "nationality", e.providers.Nationality, inputs, nationalityBatch,
+		func(in Input) bool { return in.Nationality != nil })
+	if err := e.partial(ctx, "nationality", err); err != nil {
+		return nil, err
+	}
+
+	//Use the known or the most probable nationality as the country hint
+	if e.opts.LocalizeByNationality {
+		for i := range inputs {
+			if inputs[i].CountryID == "" && inputs[i].Nationality != nil {
+				inputs[i].CountryID = *inputs[i].Nationality
+			} else if inputs[i].CountryID == "" && nationalities[i] != nil {
+				inputs[i].CountryID = nationalities[i].Country[0].CountryID
+			}
+		}
+	}
+
+	//Get ages and genders concurrently
+	g, ctx := newGroup(ctx)
+	var ages []*AgeResponse
+	var genders []*GenderResponse
+	g.Go(func() error {
+		var err error
+		ages, err = resolveBatch(ctx, e.logger, "age", e.providers.Age, inputs, ageBatch, func(in Input) bool { return in.Age != nil })
+		return e.partial(ctx, "age", err)
+	})
+	g.Go(func() error {
+		var err error
+		genders, err = resolveBatch(ctx, e.logger, "gender", e.providers.Gender, inputs, genderBatch, func(in Input) bool { return in.Gender != nil })
+		return e.partial(ctx, "gender", err)
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	//Map results back to the inputs
+	people := make([]*Person, len(inputs))
+	for i, in := range inputs {
+		people[i] = e.buildPerson(in, ages[i], genders[i], nationalities[i])
+	}
+	return people, nil
+}
+
+// ageBatch asks the provider about all inputs using the batch interface if it is implemented
+func ageBatch(ctx context.Context, p AgeProvider, inputs []Input) ([]*AgeResponse, error) {
+	if bp, ok := p.(AgeBatchProvider); ok {
+		return bp.Ages(ctx, inputs)
+	}
+	return oneByOne(inputs, func(in Input) (*AgeResponse, error) { return p.Age(ctx, in) })
+}
+
+// genderBatch asks the provider about all inputs using the batch interface if it is implemented
+func genderBatch(ctx context.Context, p GenderProvider, inputs []Input) ([]*GenderResponse, error) {
+	if bp, ok := p.(GenderBatchProvider); ok {
+		return bp.Genders(ctx, inputs)
+	}
+	return oneByOne(inputs, func(in Input) (*GenderResponse, error) { return p.Gender(ctx, in) })
+}
+
+// nationalityBatch asks the provider about all inputs using the batch interface if it is implemented
+// Answers without countries are treated as no answer
+func nationalityBatch(ctx context.Context, p NationalityProvider, inputs []Input) ([]*NationalityResponse, error) {
+	var nationalities []*NationalityResponse
+	var err error
+	if bp, ok := p.(NationalityBatchProvider); ok {
+		nationalities, err = bp.Nationalities(ctx, inputs)
+	} else {
+		nationalities, err = oneByOne(inputs, func(in Input) (*NationalityResponse, error) { return p.Nationality(ctx, in) })
+	}
+	for i := range nationalities {
+		if nationalities[i] != nil && len(nationalities[i].Country) == 0 {
+			nationalities[i] = nil
+		}
+	}
+	return nationalities, err
+}
+
+// oneByOne calls single input provider method for each input, ErrNoResult leaves a nil entry
+func oneByOne[R any](inputs []Input, call func(Input) (*R, error)) ([]*R, error) {
+	results := make([]*R, len(inputs))
+	for i, in := range inputs {
+		result, err := call(in)
+		if errors.Is(err, ErrNoResult) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// resolveBatch asks providers in order about the inputs that are still unresolved
+// If some inputs stay unresolved after every provider, the errors of all providers are returned along with the results
+// that did resolve, unresolved inputs are left nil
+// Inputs for which known returns true are not asked about and are left nil
+func resolveBatch[P any, R any](ctx context.Context, logger *zap.SugaredLogger, field string, providers []P, inputs []Input,
+	call func(context.Context, P, []Input) ([]*R, error), known func(Input) bool) ([]*R, error) {
+	results := make([]*R, len(inputs))
+	var pending []int
+	for i, in := range inputs {
+		if !known(in) {
+			pending = append(pending, i)
+		}
+	}
+
+	var errs []error
+	for i, p := range providers {
+		if len(pending) == 0 {
+			break
+		}
+
+		//Ask the provider about unresolved inputs
+		batch := make([]Input, len(pending))
+		for j, idx := range pending {
+			batch[j] = inputs[idx]
+		}
+		answers, err := call(ctx, p, batch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			logger.Debugw("Provider has no answer", "field", field, "provider", i, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		//Keep inputs the provider had no answer for
+		var stillPending []int
+		for j, idx := range pending {
+			if j < len(answers) && answers[j] != nil {
+				if r, ok := any(answers[j]).(sourced); ok {
+					r.setSource(providerName(p, field))
+				}
+				results[idx] = answers[j]
+				continue
+			}
+			stillPending = append(stillPending, idx)
+		}
+		pending = stillPending
+	}
+	if len(pending) > 0 {
+		errs = append(errs, fmt.Errorf("no %s for %q: %w", field, inputs[pending[0]].Name, ErrNoResult))
+		return results, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+// Ages makes batched requests to the agify API, each unique name is requested once per country hint
+func (p *APIProvider) Ages(ctx context.Context, inputs []Input) ([]*AgeResponse, error) {
+	return apiBatch(inputs, func(names []string, countryID string) ([]AgeResponse, error) {
+		var responses []AgeResponse
+		err := p.get(ctx, p.age, batchParams(names, countryID), &responses)
+		return responses, err
+	}, func(*AgeResponse) bool { return true })
+}
+
+// Genders makes batched requests to the genderize API, each unique name is requested once per country hint
+func (p *APIProvider) Genders(ctx context.Context, inputs []Input) ([]*GenderResponse, error) {
+	return apiBatch(inputs, func(names []string, countryID string) ([]GenderResponse, error) {
+		var responses []GenderResponse
+		err := p.get(ctx, p.gender, batchParams(names, countryID), &responses)
+		return responses, err
+	}, func(*GenderResponse) bool { return true })
+}
+
+// Nationalities makes batched requests to the nationalize API, each unique name is requested once
+// Names the API does not know any country for are left nil
+func (p *APIProvider) Nationalities(ctx context.Context, inputs []Input) ([]*NationalityResponse, error) {
+	//Nationality is not localized
+	unlocalized := make([]Input, len(inputs))
+	for i, in := range inputs {
+		unlocalized[i] = Input{Name: in.Name}
+	}
+	return apiBatch(unlocalized, func(names []string, countryID string) ([]NationalityResponse, error) {
+		var responses []NationalityResponse
+		err := p.get(ctx, p.nationality, batchParams(names, countryID), &responses)
+		return responses, err
+	}, func(r *NationalityResponse) bool { return len(r.Country) > 0 })
+}
+
+// apiBatch groups unique names by country hint, requests them in chunks the APIs accept and maps the responses back to the inputs
+// Responses for which known returns false are left nil
+func apiBatch[R any](inputs []Input, request func(names []string, countryID string) ([]R, error), known func(*R) bool) ([]*R, error) {
+	type key struct{ name, countryID string }
+
+	//Group unique names by country hint preserving the order
+	var countries []string
+	groups := make(map[string][]string)
+	seen := make(map[key]bool)
+	for _, in := range inputs {
+		k := key{in.Name, in.CountryID}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		if _, ok := groups[in.CountryID]; !ok {
+			countries = append(countries, in.CountryID)
+		}
+		groups[in.CountryID] = append(groups[in.CountryID], in.Name)
+	}
+
+	//Request each group in batches
+	answers := make(map[key]*R, len(seen))
+	for _, countryID := range countries {
+		for _, batch := range batches(groups[countryID]) {
+			responses, err := request(batch, countryID)
+			if err != nil {
+				return nil, err
+			}
+			if len(responses) != len(batch) {
+				return nil, fmt.Errorf("API returned %d results for %d names", len(responses), len(batch))
+			}
+			for i := range responses {
+				if known(&responses[i]) {
+					answers[key{batch[i], countryID}] = &responses[i]
+				}
+			}
+		}
+	}
+
+	//Map answers back to the inputs
+	results := make([]*R, len(inputs))
+	for i, in := range inputs {
+		results[i] = answers[key{in.Name, in.CountryID}]
+	}
+	return results, nil
+}
+
+// batches splits names into chunks the APIs accept in a single request
+func batches(names []string) [][]string {
+	var result [][]string
+	for len(names) > maxBatchSize {
+		result = append(result, names[:maxBatchSize])
+		names = names[maxBatchSize:]
+	}
+	if len(names) > 0 {
+		result = append(result, names)
+	}
+	return result
+}
+
+// batchParams returns query parameters for multiple names and optional country hint
+func batchParams(names []string, countryID string) url.Values {
+	params := url.Values{}
+	for _, name := range names {
+		params.Add("name[]", name)
+	}
+	if countryID != "" {
+		params.Add("country_id", countryID)
+	}
+	return params
+}
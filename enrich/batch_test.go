@@ -0,0 +1,252 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// batchServer returns a fake provider that answers batched requests with response(name, countryID) for each name
+func batchServer(t *testing.T, calls *atomic.Int64, response func(name, countryID string) any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls.Add(1)
+		names := r.URL.Query()["name[]"]
+		assert.LessOrEqual(t, len(names), maxBatchSize)
+		responses := make([]any, 0, len(names))
+		for _, name := range names {
+			responses = append(responses, response(name, r.URL.Query().Get("country_id")))
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEnrichPeople(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	var ageCalls, genderCalls, nationalityCalls atomic.Int64
+	ageServer := batchServer(t, &ageCalls, func(name, countryID string) any {
+		age := len(name)
+		if countryID == "UA" {
+			age += 100
+		}
+		return AgeResponse{Count: 10, Name: name, Age: age}
+	})
+	genderServer := batchServer(t, &genderCalls, func(name, countryID string) any {
+		return GenderResponse{Count: 10, Name: name, Gender: "male", Probability: 0.9}
+	})
+	nationalityServer := batchServer(t, &nationalityCalls, func(name, countryID string) any {
+		return NationalityResponse{Count: 10, Name: name, Country: []Country{{CountryID: "RU", Probability: 0.5}}}
+	})
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		GenderApiUrl:      genderServer.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	enricher := NewAPIEnricher(provider, logger.Sugar(), Options{})
+
+	//12 unique names, each repeated twice, and one name localized to UA
+	var inputs []Input
+	for i := range 24 {
+		inputs = append(inputs, Input{Name: fmt.Sprintf("Name%0*d", i%12+1, 0), Surname: fmt.Sprintf("Surname%d", i)})
+	}
+	inputs = append(inputs, Input{Name: "Ivan", Surname: "Ivanov", CountryID: "UA"})
+
+	people, err := enricher.EnrichPeople(context.Background(), inputs)
+	assert.NoError(t, err)
+	assert.Len(t, people, len(inputs))
+	for i, p := range people {
+		assert.Equal(t, inputs[i].Name, p.Name)
+		assert.Equal(t, inputs[i].Surname, p.Surname)
+		assert.Equal(t, inputs[i].CountryID, p.CountryHint)
+		assert.Equal(t, ptr("male"), p.Gender)
+		assert.Equal(t, ptr("RU"), p.Nationality)
+	}
+	assert.Equal(t, ptr(len("Name0")), people[0].Age)
+	assert.Equal(t, ptr(len("Ivan")+100), people[24].Age)
+
+	//Nationality of 13 names takes 2 requests, ages and genders take 2 requests without hint and 1 with the hint
+	assert.EqualValues(t, 2, nationalityCalls.Load())
+	assert.EqualValues(t, 3, ageCalls.Load())
+	assert.EqualValues(t, 3, genderCalls.Load())
+}
+
+func TestBatches(t *testing.T) {
+	names := make([]string, 25)
+	assert.Len(t, batches(names), 3)
+	assert.Len(t, batches(names)[2], 5)
+	assert.Len(t, batches(names[:10]), 1)
+	assert.Empty(t, batches(nil))
+}
+
+func TestEnrichPeopleFallback(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	var ageCalls, genderCalls, nationalityCalls atomic.Int64
+	genderServer := batchServer(t, &genderCalls, func(name, countryID string) any {
+		assert.NotEqual(t, "Maria", name)
+		return GenderResponse{Count: 10, Name: name, Gender: "male", Probability: 0.9}
+	})
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl: batchServer(t, &ageCalls, func(name, countryID string) any {
+			return AgeResponse{Count: 10, Name: name, Age: 42}
+		}).URL,
+		GenderApiUrl: genderServer.URL,
+		NationalityApiUrl: batchServer(t, &nationalityCalls, func(name, countryID string) any {
+			return NationalityResponse{Count: 10, Name: name, Country: []Country{{CountryID: "RU", Probability: 0.5}}}
+		}).URL,
+	})
+
+	//Provider without batch support is asked person by person and only the rest goes to the API
+	enricher := New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{provider},
+		Gender:      []GenderProvider{staticGender{"Maria": "female"}, provider},
+		Nationality: []NationalityProvider{provider},
+	}, Options{})
+	people, err := enricher.EnrichPeople(context.Background(), []Input{{Name: "Maria"}, {Name: "Ivan"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("female"), people[0].Gender)
+	assert.Equal(t, ptr("male"), people[1].Gender)
+	assert.EqualValues(t, 1, genderCalls.Load())
+	assert.Equal(t, "enrich.staticGender", people[0].GenderProvenance.Source)
+	assert.Equal(t, "genderize", people[1].GenderProvenance.Source)
+}
+
+func TestEnrichPeoplePartial(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Nationality provider knows only Ivan
+	var ageCalls, nationalityCalls atomic.Int64
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl: batchServer(t, &ageCalls, func(name, countryID string) any {
+			return AgeResponse{Count: 10, Name: name, Age: 42}
+		}).URL,
+		NationalityApiUrl: batchServer(t, &nationalityCalls, func(name, countryID string) any {
+			if name != "Ivan" {
+				return NationalityResponse{Name: name, Country: []Country{}}
+			}
+			return NationalityResponse{Count: 10, Name: name, Country: []Country{{CountryID: "RU", Probability: 0.5}}}
+		}).URL,
+	})
+	providers := Providers{Age: []AgeProvider{provider}, Nationality: []NationalityProvider{provider}}
+	inputs := []Input{{Name: "Ivan"}, {Name: "Xyz"}}
+
+	_, err = New(logger.Sugar(), providers, Options{}).EnrichPeople(context.Background(), inputs)
+	assert.ErrorIs(t, err, ErrNoResult)
+
+	people, err := New(logger.Sugar(), providers, Options{AllowPartial: true, LocalizeByNationality: true}).EnrichPeople(context.Background(), inputs)
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("RU"), people[0].Nationality)
+	assert.Equal(t, "RU", people[0].CountryHint)
+	assert.Equal(t, StatusOK, people[0].NationalityStatus)
+	assert.Nil(t, people[1].Nationality)
+	assert.Empty(t, people[1].CountryHint)
+	assert.Equal(t, StatusFailed, people[1].NationalityStatus)
+	assert.Equal(t, StatusOK, people[1].AgeStatus)
+	assert.Equal(t, StatusSkipped, people[1].GenderStatus)
+}
+
+func TestEnrichPeopleUnresolved(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Nationality provider knows only Ivan and the age provider is down
+	var ageCalls, nationalityCalls atomic.Int64
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	nationalityServer := batchServer(t, &nationalityCalls, func(name, countryID string) any {
+		if name != "Ivan" {
+			return NationalityResponse{Name: name, Country: []Country{}}
+		}
+		return NationalityResponse{Count: 10, Name: name, Country: []Country{{CountryID: "RU", Probability: 0.5}}}
+	})
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl: batchServer(t, &ageCalls, func(name, countryID string) any {
+			return AgeResponse{Count: 10, Name: name, Age: 42}
+		}).URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	inputs := []Input{{Name: "Ivan"}, {Name: "Xyz"}}
+	ctx := AllowUnresolved(context.Background())
+
+	//Names without an answer are reported as failed
+	people, err := New(logger.Sugar(), Providers{Age: []AgeProvider{provider}, Nationality: []NationalityProvider{provider}}, Options{}).
+		EnrichPeople(ctx, inputs)
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("RU"), people[0].Nationality)
+	assert.Nil(t, people[1].Nationality)
+	assert.Equal(t, StatusFailed, people[1].NationalityStatus)
+	assert.Equal(t, ptr(42), people[1].Age)
+
+	//Failures of the providers are still returned
+	down := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         failing.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	_, err = New(logger.Sugar(), Providers{Age: []AgeProvider{down}, Nationality: []NationalityProvider{down}}, Options{}).
+		EnrichPeople(ctx, inputs)
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+}
+
+func TestEnrichPeopleKnownFields(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Only people whose fields are not known are asked about
+	var ageCalls, genderCalls, nationalityCalls atomic.Int64
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl: batchServer(t, &ageCalls, func(name, countryID string) any {
+			assert.Equal(t, "Maria", name)
+			return AgeResponse{Count: 10, Name: name, Age: 42}
+		}).URL,
+		GenderApiUrl: batchServer(t, &genderCalls, func(name, countryID string) any {
+			return GenderResponse{Count: 10, Name: name, Gender: "male", Probability: 0.9}
+		}).URL,
+		NationalityApiUrl: batchServer(t, &nationalityCalls, func(name, countryID string) any {
+			return NationalityResponse{Count: 10, Name: name, Country: []Country{{CountryID: "RU", Probability: 0.5}}}
+		}).URL,
+	})
+	people, err := NewAPIEnricher(provider, logger.Sugar(), Options{}).EnrichPeople(context.Background(), []Input{
+		{Name: "Ivan", Age: ptr(30), Gender: ptr("male"), Nationality: ptr("UA")},
+		{Name: "Maria"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(30), people[0].Age)
+	assert.Equal(t, StatusProvided, people[0].AgeStatus)
+	assert.Equal(t, ptr("UA"), people[0].Nationality)
+	assert.Equal(t, StatusProvided, people[0].NationalityStatus)
+	assert.Equal(t, ptr(42), people[1].Age)
+	assert.Equal(t, StatusOK, people[1].AgeStatus)
+	assert.EqualValues(t, 1, ageCalls.Load())
+	assert.EqualValues(t, 1, nationalityCalls.Load())
+}
@@ -0,0 +1,119 @@
+package enrich
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BreakerOptions configures circuit breakers of the providers
+// FailureThreshold of 0 disables the breakers
+type BreakerOptions struct {
+	FailureThreshold int
+	CoolDown         time.Duration
+}
+
+// DefaultBreakerOptions returns options that open the breaker after 5 consecutive failures for 30 seconds
+func DefaultBreakerOptions() BreakerOptions {
+	return BreakerOptions{
+		FailureThreshold: 5,
+		CoolDown:         time.Second * 30,
+	}
+}
+
+type breakerState string
+
+const (
+	stateClosed   breakerState = "closed"
+	stateOpen     breakerState = "open"
+	stateHalfOpen breakerState = "half-open"
+)
+
+// breaker is a circuit breaker of a single provider
+// After FailureThreshold consecutive failures it opens and rejects requests for CoolDown,
+// then lets a single trial request through and closes again if it succeeds
+type breaker struct {
+	provider string
+	opts     BreakerOptions
+	logger   *zap.SugaredLogger
+
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func newBreaker(provider string, opts BreakerOptions, logger *zap.SugaredLogger) *breaker {
+	return &breaker{
+		provider: provider,
+		opts:     opts,
+		logger:   logger,
+		state:    stateClosed,
+	}
+}
+
+// allow returns an error if the request must not be sent to the provider
+func (b *breaker) allow() error {
+	if b.opts.FailureThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Now().Before(b.openUntil) {
+			return &CircuitOpenError{Provider: b.provider, Until: b.openUntil}
+		}
+		b.setState(stateHalfOpen)
+		b.trial = true
+		return nil
+	case stateHalfOpen:
+		//Only one trial request at a time
+		if b.trial {
+			return &CircuitOpenError{Provider: b.provider, Until: b.openUntil}
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the result of an allowed request
+func (b *breaker) record(success bool) {
+	if b.opts.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.failures = 0
+		if b.state != stateClosed {
+			b.setState(stateClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.opts.FailureThreshold {
+		b.openUntil = time.Now().Add(b.opts.CoolDown)
+		b.setState(stateOpen)
+	}
+}
+
+// release lets another trial request through without changing the state
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// setState changes the state and logs the transition, must be called with mu held
+func (b *breaker) setState(state breakerState) {
+	b.logger.Warnw("Circuit breaker state changed", "provider", b.provider, "from", b.state, "to", state, "failures", b.failures)
+	b.state = state
+}
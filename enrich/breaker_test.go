@@ -0,0 +1,70 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Provider is down until healthy is set
+	var healthy atomic.Bool
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"count":1000,"name":"Ivan","country":[{"country_id":"RU","probability":0.4}]}`)
+	}))
+	t.Cleanup(server.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		NationalityApiUrl: server.URL,
+		Breaker:           BreakerOptions{FailureThreshold: 2, CoolDown: time.Millisecond * 50},
+	})
+
+	//Two failures open the breaker
+	for range 2 {
+		_, err := provider.Nationality(context.Background(), Input{Name: "Ivan"})
+		var statusErr *StatusError
+		assert.ErrorAs(t, err, &statusErr)
+	}
+
+	//Open breaker rejects requests without calling the provider
+	_, err = provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, calls.Load())
+
+	//Failed trial request after the cool-down opens the breaker again
+	time.Sleep(time.Millisecond * 60)
+	_, err = provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	_, err = provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 3, calls.Load())
+
+	//Successful trial request closes the breaker
+	healthy.Store(true)
+	time.Sleep(time.Millisecond * 60)
+	nationality, err := provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "RU", nationality.Country[0].CountryID)
+	_, err = provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, calls.Load())
+}
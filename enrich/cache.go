@@ -0,0 +1,329 @@
+package enrich
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultCacheSize = 1000
+	DefaultCacheTTL  = time.Hour * 24 * 30
+)
+
+// CacheStore persists enrichment results so that they survive restarts
+type CacheStore interface {
+	GetCached(ctx context.Context, key string) ([]byte, bool, error)
+	SetCached(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheOptions configures the caching enricher
+// Store is optional, without it results are kept in memory only
+// KeyBySurname caches results per surname and patronymic too, it has to be set if a gender provider uses them,
+// like SlavicGenderProvider does
+type CacheOptions struct {
+	Size         int
+	TTL          time.Duration
+	Store        CacheStore
+	KeyBySurname bool
+}
+
+// cachedResult is the name dependent part of the enriched person
+type cachedResult struct {
+	Age                    *int      `json:"age"`
+	Gender                 *string   `json:"gender"`
+	Nationality            *string   `json:"nationality"`
+	CountryHint            string    `json:"country_hint"`
+	AgeSampleCount         int       `json:"age_sample_count"`
+	GenderProbability      float64   `json:"gender_probability"`
+	NationalityProbability float64   `json:"nationality_probability"`
+	NationalityCandidates  []Country `json:"nationality_candidates"`
+
+	//Entries cached before the statuses were added have none, they were fully enriched
+	AgeStatus         FieldStatus `json:"age_status,omitempty"`
+	GenderStatus      FieldStatus `json:"gender_status,omitempty"`
+	NationalityStatus FieldStatus `json:"nationality_status,omitempty"`
+
+	//Entries cached before the provenance was added have none
+	AgeProvenance         *Provenance `json:"age_provenance,omitempty"`
+	GenderProvenance      *Provenance `json:"gender_provenance,omitempty"`
+	NationalityProvenance *Provenance `json:"nationality_provenance,omitempty"`
+}
+
+// person returns the person from input enriched with the cached result, fields known from the input override the cached ones
+func (r cachedResult) person(in Input) *Person {
+	person := &Person{
+		Name:                   in.Name,
+		Surname:                in.Surname,
+		Patronymic:             in.Patronymic,
+		CountryHint:            r.CountryHint,
+		Age:                    r.Age,
+		Gender:                 r.Gender,
+		Nationality:            r.Nationality,
+		AgeSampleCount:         r.AgeSampleCount,
+		GenderProbability:      r.GenderProbability,
+		NationalityProbability: r.NationalityProbability,
+		NationalityCandidates:  r.NationalityCandidates,
+		AgeStatus:              statusOrOK(r.AgeStatus),
+		GenderStatus:           statusOrOK(r.GenderStatus),
+		NationalityStatus:      statusOrOK(r.NationalityStatus),
+		AgeProvenance:          r.AgeProvenance,
+		GenderProvenance:       r.GenderProvenance,
+		NationalityProvenance:  r.NationalityProvenance,
+	}
+	applyKnown(person, in)
+	return person
+}
+
+// statusOrOK returns StatusOK for entries cached without a status
+func statusOrOK(status FieldStatus) FieldStatus {
+	if status == "" {
+		return StatusOK
+	}
+	return status
+}
+
+type cacheEntry struct {
+	key       string
+	result    cachedResult
+	expiresAt time.Time
+}
+
+type cachingEnricher struct {
+	next    Enricher
+	logger  *zap.SugaredLogger
+	opts    CacheOptions
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// NewCachingEnricher wraps next with a bounded in-memory LRU cache keyed by normalized name
+func NewCachingEnricher(next Enricher, logger *zap.SugaredLogger, opts CacheOptions) Enricher {
+	if opts.Size <= 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	return &cachingEnricher{
+		next:    next,
+		logger:  logger,
+		opts:    opts,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// EnrichPerson returns cached age, gender and nationality for the name and country hint or enriches the person using the wrapped enricher
+// People with known nationality are always enriched by the wrapped enricher, the nationality may be used as the country hint
+func (c *cachingEnricher) EnrichPerson(ctx context.Context, in Input) (*Person, error) {
+	in = normalizeInput(in)
+	key := c.cacheKey(in)
+	if result, ok := c.lookup(ctx, in); ok {
+		return result.person(in), nil
+	}
+
+	//Enrich the person and remember the result
+	person, err := c.next.EnrichPerson(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	c.remember(ctx, key, person)
+	return person, nil
+}
+
+// EnrichPeople returns cached results for known names and enriches the rest in a single batch using the wrapped enricher
+// People with known nationality are enriched by the wrapped enricher as in EnrichPerson
+func (c *cachingEnricher) EnrichPeople(ctx context.Context, inputs []Input) ([]*Person, error) {
+	inputs = normalizeInputs(inputs)
+	people := make([]*Person, len(inputs))
+	var misses []Input
+	var missIndexes []int
+	for i, in := range inputs {
+		if result, ok := c.lookup(ctx, in); ok {
+			people[i] = result.person(in)
+			continue
+		}
+		misses = append(misses, in)
+		missIndexes = append(missIndexes, i)
+	}
+	if len(misses) == 0 {
+		return people, nil
+	}
+
+	//Enrich the people missing from the cache and remember the results
+	enriched, err := c.next.EnrichPeople(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for i, person := range enriched {
+		c.remember(ctx, c.cacheKey(misses[i]), person)
+		people[missIndexes[i]] = person
+	}
+	return people, nil
+}
+
+// skipCacheKey marks contexts whose enrichment must not use cached results
+type skipCacheKey struct{}
+
+// SkipCache returns a context that makes the caching enricher ask the wrapped enricher even for cached names
+// Fresh results still replace the cached ones
+func SkipCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// lookup looks up the result for the input in memory and then in the persistent store
+// Inputs with known nationality are not looked up, the cached result may be localized to another country
+func (c *cachingEnricher) lookup(ctx context.Context, in Input) (cachedResult, bool) {
+	key := c.cacheKey(in)
+	if skip, _ := ctx.Value(skipCacheKey{}).(bool); skip {
+		c.logger.Debugw("Enrichment cache skipped", "key", key)
+		return cachedResult{}, false
+	}
+	if in.Nationality != nil {
+		c.logger.Debugw("Enrichment cache skipped for known nationality", "key", key)
+		return cachedResult{}, false
+	}
+	result, ok := c.getMemory(key)
+	if !ok {
+		result, ok = c.getStore(ctx, key)
+		if ok {
+			c.setMemory(key, result)
+		}
+	}
+	if ok {
+		c.logger.Infow("Enrichment cache hit", "key", key, "hits", c.hits.Add(1), "misses", c.misses.Load())
+	} else {
+		c.logger.Infow("Enrichment cache miss", "key", key, "hits", c.hits.Load(), "misses", c.misses.Add(1))
+	}
+	return result, ok
+}
+
+// remember saves the name dependent part of the person in memory and in the persistent store
+// Partially enriched people are not cached so that failed fields are retried on the next request
+// People with fields known from the input are not cached either, providers were not asked about those fields
+func (c *cachingEnricher) remember(ctx context.Context, key string, person *Person) {
+	if person.AgeStatus == StatusFailed || person.GenderStatus == StatusFailed || person.NationalityStatus == StatusFailed {
+		c.logger.Debugw("Partial enrichment result is not cached", "key", key)
+		return
+	}
+	if person.AgeStatus == StatusProvided || person.GenderStatus == StatusProvided || person.NationalityStatus == StatusProvided {
+		c.logger.Debugw("Enrichment result with provided fields is not cached", "key", key)
+		return
+	}
+	result := cachedResult{
+		Age:                    person.Age,
+		Gender:                 person.Gender,
+		Nationality:            person.Nationality,
+		CountryHint:            person.CountryHint,
+		AgeSampleCount:         person.AgeSampleCount,
+		GenderProbability:      person.GenderProbability,
+		NationalityProbability: person.NationalityProbability,
+		NationalityCandidates:  person.NationalityCandidates,
+		AgeStatus:              person.AgeStatus,
+		GenderStatus:           person.GenderStatus,
+		NationalityStatus:      person.NationalityStatus,
+		AgeProvenance:          person.AgeProvenance,
+		GenderProvenance:       person.GenderProvenance,
+		NationalityProvenance:  person.NationalityProvenance,
+	}
+	c.setMemory(key, result)
+	c.setStore(ctx, key, result)
+}
+
+// getMemory returns unexpired result from the LRU and marks it as recently used
+func (c *cachingEnricher) getMemory(key string) (cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return cachedResult{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return cachedResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// setMemory adds result to the LRU evicting the least recently used entry if the cache is full
+func (c *cachingEnricher) setMemory(key string, result cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.opts.TTL)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key: key, result: result, expiresAt: expiresAt}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result, expiresAt: expiresAt})
+	if c.order.Len() > c.opts.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// getStore loads result from the persistent store, errors are logged and treated as a miss
+func (c *cachingEnricher) getStore(ctx context.Context, key string) (cachedResult, bool) {
+	if c.opts.Store == nil {
+		return cachedResult{}, false
+	}
+	value, ok, err := c.opts.Store.GetCached(ctx, key)
+	if err != nil {
+		c.logger.Errorw("Error reading enrichment cache", "key", key, "error", err)
+		return cachedResult{}, false
+	}
+	if !ok {
+		return cachedResult{}, false
+	}
+	var result cachedResult
+	if err := json.Unmarshal(value, &result); err != nil {
+		c.logger.Errorw("Error decoding enrichment cache entry", "key", key, "error", err)
+		return cachedResult{}, false
+	}
+	return result, true
+}
+
+// setStore saves result to the persistent store, errors are logged and ignored
+func (c *cachingEnricher) setStore(ctx context.Context, key string, result cachedResult) {
+	if c.opts.Store == nil {
+		return
+	}
+	value, err := json.Marshal(result)
+	if err != nil {
+		c.logger.Errorw("Error encoding enrichment cache entry", "key", key, "error", err)
+		return
+	}
+	if err := c.opts.Store.SetCached(ctx, key, value, c.opts.TTL); err != nil {
+		c.logger.Errorw("Error writing enrichment cache", "key", key, "error", err)
+	}
+}
+
+// cacheKey normalizes the name so that different spellings of the same name share the cache entry
+// Results localized to a country are cached separately
+// With KeyBySurname the surname and patronymic are added as spelled, since gender rules depend on the spelling
+func (c *cachingEnricher) cacheKey(in Input) string {
+	key := strings.ToLower(NormalizeName(in.Name))
+	if in.CountryID != "" {
+		key += "|" + strings.ToUpper(in.CountryID)
+	}
+	if c.opts.KeyBySurname {
+		key += "|" + strings.ToLower(strings.TrimSpace(in.surnameOriginal)) + "|" + strings.ToLower(strings.TrimSpace(in.patronymicOriginal))
+	}
+	return key
+}
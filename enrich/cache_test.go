@@ -0,0 +1,228 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// countingEnricher counts enriched people, nationality status is reported as failed if failNationality is set
+type countingEnricher struct {
+	calls           int
+	failNationality bool
+}
+
+func (e *countingEnricher) EnrichPerson(ctx context.Context, in Input) (*Person, error) {
+	e.calls++
+	person := &Person{Name: in.Name, Surname: in.Surname, Patronymic: in.Patronymic, Age: ptr(42), Gender: ptr("male"), Nationality: ptr("RU")}
+	if e.failNationality {
+		person.Nationality, person.NationalityStatus = nil, StatusFailed
+	}
+	return person, nil
+}
+
+func (e *countingEnricher) EnrichPeople(ctx context.Context, inputs []Input) ([]*Person, error) {
+	people := make([]*Person, len(inputs))
+	for i, in := range inputs {
+		e.calls++
+		people[i] = &Person{Name: in.Name, Surname: in.Surname, Patronymic: in.Patronymic, Age: ptr(42), Gender: ptr("male"), Nationality: ptr("RU")}
+	}
+	return people, nil
+}
+
+// mapCacheStore is an in-memory CacheStore
+type mapCacheStore map[string][]byte
+
+func (s mapCacheStore) GetCached(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := s[key]
+	return value, ok, nil
+}
+
+func (s mapCacheStore) SetCached(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s[key] = value
+	return nil
+}
+
+func TestCachingEnricher(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	next := &countingEnricher{}
+	persistent := mapCacheStore{}
+	enricher := NewCachingEnricher(next, logger.Sugar(), CacheOptions{Size: 1, Store: persistent})
+
+	//First call is a miss, the second one with a differently spelled name is a hit and the name is normalized
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", Patronymic: "Ivanovich"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(42), person.Age)
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: " ИВАН ", Surname: "petrov", Patronymic: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", person.Name)
+	assert.Equal(t, "Petrov", person.Surname)
+	assert.Equal(t, ptr("RU"), person.Nationality)
+	assert.Equal(t, 1, next.calls)
+	assert.Contains(t, persistent, "ivan")
+
+	//Maria evicts Ivan from memory, but Ivan is still found in the persistent store
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Maria", Surname: "Ivanova", Patronymic: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", Patronymic: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestCachingEnricherExpiry(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	next := &countingEnricher{}
+	enricher := NewCachingEnricher(next, logger.Sugar(), CacheOptions{TTL: time.Millisecond})
+
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", Patronymic: ""})
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond * 5)
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", Patronymic: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestCachingEnricherEnrichPeople(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	next := &countingEnricher{}
+	enricher := NewCachingEnricher(next, logger.Sugar(), CacheOptions{})
+
+	//Ivan is cached, only Maria and Olga are enriched by the wrapped enricher
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov"})
+	assert.NoError(t, err)
+	people, err := enricher.EnrichPeople(context.Background(), []Input{{Name: "Maria"}, {Name: "ivan", Surname: "Petrov"}, {Name: "Olga"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+	assert.Equal(t, "Maria", people[0].Name)
+	assert.Equal(t, "Petrov", people[1].Surname)
+	assert.Equal(t, "Olga", people[2].Name)
+
+	//Everyone is cached now
+	_, err = enricher.EnrichPeople(context.Background(), []Input{{Name: "Maria"}, {Name: "Olga"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+
+	//Cached names are enriched again when the cache is skipped
+	_, err = enricher.EnrichPeople(SkipCache(context.Background()), []Input{{Name: "Maria"}, {Name: "Olga"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, next.calls)
+}
+
+func TestCachingEnricherSkipsPartialResults(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	next := &countingEnricher{failNationality: true}
+	persistent := mapCacheStore{}
+	enricher := NewCachingEnricher(next, logger.Sugar(), CacheOptions{Store: persistent, KeyBySurname: true})
+
+	//Failed fields are retried on the next request
+	for range 2 {
+		person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+		assert.NoError(t, err)
+		assert.Equal(t, StatusFailed, person.NationalityStatus)
+	}
+	assert.Equal(t, 2, next.calls)
+	assert.Empty(t, persistent)
+
+	//Complete result is cached
+	next.failNationality = false
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+	assert.Equal(t, StatusOK, person.NationalityStatus)
+
+	//Known fields override the cached ones
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Age: ptr(30)})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+	assert.Equal(t, ptr(30), person.Age)
+	assert.Equal(t, StatusProvided, person.AgeStatus)
+}
+
+func TestCachingEnricherKnownNationality(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.csv", "name,country_id,age,age_count,nationality\n"+
+		"Ivan,,,,RU:0.9\n"+
+		"Ivan,RU,30,100,\n"+
+		"Ivan,UA,40,100,\n")
+	dictionary, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+	enricher := NewCachingEnricher(New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{dictionary},
+		Nationality: []NationalityProvider{dictionary},
+	}, Options{LocalizeByNationality: true, AllowPartial: true}), logger.Sugar(), CacheOptions{})
+
+	//Age is localized to the looked up nationality and cached
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(30), person.Age)
+	assert.Equal(t, "RU", person.CountryHint)
+
+	//Known nationality is used as the hint instead of the cached result
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Nationality: ptr("UA")})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(40), person.Age)
+	assert.Equal(t, "UA", person.CountryHint)
+	people, err := enricher.EnrichPeople(context.Background(), []Input{{Name: "Ivan", Nationality: ptr("UA")}, {Name: "Ivan"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(40), people[0].Age)
+	assert.Equal(t, ptr(30), people[1].Age)
+}
+
+func TestCachingEnricherKeyBySurname(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.csv", "name,age,age_count,nationality\nSasha,30,100,RU:0.9\n")
+	dictionary, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+	persistent := mapCacheStore{}
+	enricher := NewCachingEnricher(New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{dictionary},
+		Gender:      []GenderProvider{NewSlavicGenderProvider(logger.Sugar())},
+		Nationality: []NationalityProvider{dictionary},
+	}, Options{}), logger.Sugar(), CacheOptions{Store: persistent, KeyBySurname: true})
+
+	//Gender inferred from one surname is not reused for another one with the same first name
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Sasha", Surname: "Иванова"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("female"), person.Gender)
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Sasha", Surname: "Иванов"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("male"), person.Gender)
+	assert.Len(t, persistent, 2)
+}
@@ -0,0 +1,282 @@
+package enrich
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// dictionaryEntry is a single name in the dictionary dataset
+// Names are matched after NormalizeName, so Cyrillic and Latin spellings of the same name share the entry
+// Entries with CountryID are used for inputs localized to that country, the rest for any input
+// Age, gender and nationality are optional, missing ones are left to other providers
+// Ages without AgeCount are trusted regardless of the sample count threshold
+type dictionaryEntry struct {
+	Name              string    `json:"name"`
+	CountryID         string    `json:"country_id"`
+	Age               *int      `json:"age"`
+	AgeCount          *int      `json:"age_count"`
+	Gender            string    `json:"gender"`
+	GenderProbability float64   `json:"gender_probability"`
+	GenderCount       int       `json:"gender_count"`
+	Nationality       []Country `json:"nationality"`
+}
+
+type dictionaryKey struct {
+	name      string
+	countryID string
+}
+
+// DictionaryProvider answers age, gender and nationality from a local dataset without network requests
+// The dataset is a JSON array of entries or a CSV file with a header, the format is chosen by the file extension
+//
+// CSV columns are name, country_id, age, age_count, gender, gender_probability, gender_count and nationality,
+// where nationality is a list of country:probability pairs separated by semicolons, e.g. RU:0.4;UA:0.2
+type DictionaryProvider struct {
+	path    string
+	logger  *zap.SugaredLogger
+	mu      sync.RWMutex
+	entries map[dictionaryKey]*dictionaryEntry
+}
+
+// NewDictionaryProvider loads the dataset from path and returns the provider
+func NewDictionaryProvider(path string, logger *zap.SugaredLogger) (*DictionaryProvider, error) {
+	p := &DictionaryProvider{path: path, logger: logger}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload reads the dataset from the file again
+// If the file cannot be loaded, the previous dataset is kept and the error is returned
+func (p *DictionaryProvider) Reload() error {
+	entries, err := loadDictionary(p.path)
+	if err != nil {
+		return fmt.Errorf("error loading dictionary %s: %w", p.path, err)
+	}
+	p.mu.Lock()
+	p.entries = entries
+	p.mu.Unlock()
+	p.logger.Infow("Dictionary loaded", "path", p.path, "entries", len(entries))
+	return nil
+}
+
+// ProviderName returns dictionary for every field
+func (p *DictionaryProvider) ProviderName(field string) string {
+	return "dictionary"
+}
+
+// Age returns age of the name localized to in.CountryID if the dataset has it
+func (p *DictionaryProvider) Age(ctx context.Context, in Input) (*AgeResponse, error) {
+	entry := p.lookup(in, func(e *dictionaryEntry) bool { return e.Age != nil })
+	if entry == nil {
+		return nil, ErrNoResult
+	}
+	if entry.AgeCount == nil {
+		return &AgeResponse{Name: in.Name, Age: *entry.Age, Uncounted: true}, nil
+	}
+	return &AgeResponse{Count: *entry.AgeCount, Name: in.Name, Age: *entry.Age}, nil
+}
+
+// Gender returns gender of the name localized to in.CountryID if the dataset has it
+func (p *DictionaryProvider) Gender(ctx context.Context, in Input) (*GenderResponse, error) {
+	entry := p.lookup(in, func(e *dictionaryEntry) bool { return e.Gender != "" })
+	if entry == nil {
+		return nil, ErrNoResult
+	}
+	return &GenderResponse{Count: entry.GenderCount, Name: in.Name, Gender: entry.Gender, Probability: entry.GenderProbability}, nil
+}
+
+// Nationality returns countries of the name ranked by probability
+func (p *DictionaryProvider) Nationality(ctx context.Context, in Input) (*NationalityResponse, error) {
+	entry := p.lookup(Input{Name: in.Name}, func(e *dictionaryEntry) bool { return len(e.Nationality) > 0 })
+	if entry == nil {
+		return nil, ErrNoResult
+	}
+	return &NationalityResponse{Name: in.Name, Country: entry.Nationality}, nil
+}
+
+// lookup returns the localized entry if it has the field and the entry for any country otherwise
+func (p *DictionaryProvider) lookup(in Input, has func(*dictionaryEntry) bool) *dictionaryEntry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := strings.ToLower(NormalizeName(in.Name))
+	if in.CountryID != "" {
+		if entry, ok := p.entries[dictionaryKey{name, strings.ToUpper(in.CountryID)}]; ok && has(entry) {
+			return entry
+		}
+	}
+	if entry, ok := p.entries[dictionaryKey{name, ""}]; ok && has(entry) {
+		return entry
+	}
+	return nil
+}
+
+// loadDictionary reads and validates the dataset
+func loadDictionary(path string) (map[dictionaryKey]*dictionaryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []dictionaryEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&list)
+	case ".csv":
+		list, err = readDictionaryCSV(f)
+	default:
+		err = errors.New("unsupported format, expected .json or .csv")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[dictionaryKey]*dictionaryEntry, len(list))
+	for i := range list {
+		entry := &list[i]
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		key := dictionaryKey{strings.ToLower(NormalizeName(entry.Name)), entry.CountryID}
+		if _, ok := entries[key]; ok {
+			return nil, fmt.Errorf("entry %d: duplicate name %q", i+1, entry.Name)
+		}
+		entries[key] = entry
+	}
+	return entries, nil
+}
+
+// validate checks the entry, normalizes its country codes and ranks its nationalities by probability
+// Country codes are normalized the same way as the /get filters, ages are checked against the same range as provider answers
+func (e *dictionaryEntry) validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return errors.New("name is empty")
+	}
+	e.CountryID = NormalizeCountryID(e.CountryID)
+	if e.CountryID != "" && !IsCountryID(e.CountryID) {
+		return fmt.Errorf("invalid country_id %q", e.CountryID)
+	}
+	if e.Age != nil && (*e.Age < 0 || *e.Age > maxAge) {
+		return fmt.Errorf("age %d is out of range", *e.Age)
+	}
+	if e.AgeCount != nil && *e.AgeCount < 0 {
+		return fmt.Errorf("negative age_count %d", *e.AgeCount)
+	}
+	if e.Gender != "" && e.Gender != "male" && e.Gender != "female" {
+		return fmt.Errorf("invalid gender %q", e.Gender)
+	}
+	if e.GenderProbability < 0 || e.GenderProbability > 1 {
+		return fmt.Errorf("invalid gender probability %v", e.GenderProbability)
+	}
+	for i := range e.Nationality {
+		c := &e.Nationality[i]
+		c.CountryID = NormalizeCountryID(c.CountryID)
+		if !IsCountryID(c.CountryID) || c.Probability < 0 || c.Probability > 1 {
+			return fmt.Errorf("invalid nationality %s:%v", c.CountryID, c.Probability)
+		}
+	}
+	sort.SliceStable(e.Nationality, func(i, j int) bool {
+		return e.Nationality[i].Probability > e.Nationality[j].Probability
+	})
+	return nil
+}
+
+// readDictionaryCSV reads entries from CSV with a header, columns may go in any order and only name is required
+func readDictionaryCSV(r io.Reader) ([]dictionaryEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("name column is missing")
+	}
+
+	var entries []dictionaryEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry, err := parseDictionaryRecord(record, columns)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// parseDictionaryRecord converts CSV record to the entry, empty cells are left unset
+func parseDictionaryRecord(record []string, columns map[string]int) (dictionaryEntry, error) {
+	get := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entry := dictionaryEntry{Name: get("name"), CountryID: get("country_id"), Gender: get("gender")}
+	var err error
+	if v := get("age"); v != "" {
+		age, err := strconv.Atoi(v)
+		if err != nil {
+			return entry, fmt.Errorf("invalid age: %w", err)
+		}
+		entry.Age = &age
+	}
+	if v := get("age_count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return entry, fmt.Errorf("invalid age_count: %w", err)
+		}
+		entry.AgeCount = &count
+	}
+	if v := get("gender_probability"); v != "" {
+		if entry.GenderProbability, err = strconv.ParseFloat(v, 64); err != nil {
+			return entry, fmt.Errorf("invalid gender_probability: %w", err)
+		}
+	}
+	if v := get("gender_count"); v != "" {
+		if entry.GenderCount, err = strconv.Atoi(v); err != nil {
+			return entry, fmt.Errorf("invalid gender_count: %w", err)
+		}
+	}
+	if v := get("nationality"); v != "" {
+		for _, pair := range strings.Split(v, ";") {
+			countryID, probability, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok {
+				return entry, fmt.Errorf("invalid nationality %q, expected country:probability", pair)
+			}
+			c := Country{CountryID: countryID}
+			if c.Probability, err = strconv.ParseFloat(probability, 64); err != nil {
+				return entry, fmt.Errorf("invalid nationality probability: %w", err)
+			}
+			entry.Nationality = append(entry.Nationality, c)
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,159 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// writeDictionary writes the dataset to a temporary file with the given name
+func writeDictionary(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestDictionaryProviderJSON(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.json", `[
+		{"name":"Ivan","age":42,"age_count":1000,"gender":"male","gender_probability":0.99,"nationality":[{"country_id":"UA","probability":0.2},{"country_id":"RU","probability":0.4}]},
+		{"name":"Ivan","country_id":"UA","age":35,"age_count":100},
+		{"name":"Саша","nationality":[{"country_id":"ru","probability":0.5}]}
+	]`)
+	provider, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+
+	//Names are matched case insensitively and localized entries are preferred
+	age, err := provider.Age(context.Background(), Input{Name: "ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, &AgeResponse{Count: 1000, Name: "ivan", Age: 42}, age)
+	age, err = provider.Age(context.Background(), Input{Name: "Ivan", CountryID: "ua"})
+	assert.NoError(t, err)
+	assert.Equal(t, 35, age.Age)
+
+	//Localized entry without gender falls back to the entry for any country
+	gender, err := provider.Gender(context.Background(), Input{Name: "Ivan", CountryID: "UA"})
+	assert.NoError(t, err)
+	assert.Equal(t, "male", gender.Gender)
+	assert.Equal(t, 0.99, gender.Probability)
+
+	//Nationalities are ranked by probability
+	nationality, err := provider.Nationality(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Country{{CountryID: "RU", Probability: 0.4}, {CountryID: "UA", Probability: 0.2}}, nationality.Country)
+
+	//Country codes are normalized
+	nationality, err = provider.Nationality(context.Background(), Input{Name: "Sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Country{{CountryID: "RU", Probability: 0.5}}, nationality.Country)
+
+	//Missing fields and names are left to other providers
+	_, err = provider.Gender(context.Background(), Input{Name: "Sasha"})
+	assert.ErrorIs(t, err, ErrNoResult)
+	_, err = provider.Age(context.Background(), Input{Name: "Maria"})
+	assert.ErrorIs(t, err, ErrNoResult)
+}
+
+func TestDictionaryProviderCSV(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.csv", "name,gender,gender_probability,age,nationality\n"+
+		"Maria,female,0.98,,RU:0.3;UA:0.5\n"+
+		"Oleg,male,1,50,\n")
+	provider, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+
+	enricher := New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{provider},
+		Gender:      []GenderProvider{provider},
+		Nationality: []NationalityProvider{provider},
+	}, Options{})
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Maria"})
+	assert.ErrorIs(t, err, ErrNoResult)
+
+	gender, err := provider.Gender(context.Background(), Input{Name: "Maria"})
+	assert.NoError(t, err)
+	assert.Equal(t, "female", gender.Gender)
+	nationality, err := provider.Nationality(context.Background(), Input{Name: "Maria"})
+	assert.NoError(t, err)
+	assert.Equal(t, "UA", nationality.Country[0].CountryID)
+	age, err := provider.Age(context.Background(), Input{Name: "Oleg"})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, age.Age)
+}
+
+func TestDictionaryProviderReload(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.csv", "name,age\nIvan,42\n")
+	provider, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+
+	//New dataset replaces the old one
+	assert.NoError(t, os.WriteFile(path, []byte("name,age\nIvan,43\n"), 0o600))
+	assert.NoError(t, provider.Reload())
+	age, err := provider.Age(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, 43, age.Age)
+
+	//Invalid dataset keeps the old one
+	assert.NoError(t, os.WriteFile(path, []byte("name,age\nIvan,old\n"), 0o600))
+	assert.Error(t, provider.Reload())
+	age, err = provider.Age(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, 43, age.Age)
+
+	//Invalid files are rejected at startup
+	_, err = NewDictionaryProvider(writeDictionary(t, "names.json", `[{"name":"Ivan","gender":"unknown"}]`), logger.Sugar())
+	assert.Error(t, err)
+	_, err = NewDictionaryProvider(writeDictionary(t, "names.json", `[{"name":"Ivan","age":200}]`), logger.Sugar())
+	assert.Error(t, err)
+	_, err = NewDictionaryProvider(writeDictionary(t, "names.json", `[{"name":"Ivan","nationality":[{"country_id":"Russia","probability":0.4}]}]`), logger.Sugar())
+	assert.Error(t, err)
+	_, err = NewDictionaryProvider(writeDictionary(t, "names.csv", "name,country_id\nIvan,RUS\n"), logger.Sugar())
+	assert.Error(t, err)
+	_, err = NewDictionaryProvider(writeDictionary(t, "names.txt", "Ivan"), logger.Sugar())
+	assert.Error(t, err)
+}
+
+func TestDictionaryAgeWithoutCount(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	path := writeDictionary(t, "names.csv", "name,age,age_count\nIvan,42,\nOleg,50,5\n")
+	provider, err := NewDictionaryProvider(path, logger.Sugar())
+	assert.NoError(t, err)
+	enricher := New(logger.Sugar(), Providers{Age: []AgeProvider{provider}}, Options{MinAgeSampleCount: 10, AllowPartial: true})
+
+	//Age without a sample count is kept regardless of the threshold
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(42), person.Age)
+	assert.Equal(t, StatusOK, person.AgeStatus)
+
+	//Counted age is still checked against the threshold
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Oleg"})
+	assert.NoError(t, err)
+	assert.Nil(t, person.Age)
+}
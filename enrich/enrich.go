@@ -2,205 +2,366 @@ package enrich
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"net/http"
-	"net/url"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// Options configures how the enricher combines the answers of the providers
+type Options struct {
+	//Guesses below these thresholds are left unknown
+	MinAgeSampleCount         int
+	MinGenderProbability      float64
+	MinNationalityProbability float64
+
+	//Localize age and gender to the most probable nationality if the input has no country hint
+	LocalizeByNationality bool
+
+	//Return the person with the fields that did resolve instead of failing when some providers fail
+	AllowPartial bool
+}
+
+// FieldStatus tells how the enrichment of a single field went
+type FieldStatus string
+
 const (
-	ageApiUrl         = "https://api.agify.io/"
-	genderApiUrl      = "https://api.genderize.io/"
-	nationalityApiUrl = "https://api.nationalize.io/"
+	StatusOK       FieldStatus = "ok"       //provider answered, the value may still be unknown if the answer was not confident
+	StatusFailed   FieldStatus = "failed"   //every provider failed or had no answer
+	StatusSkipped  FieldStatus = "skipped"  //no provider is configured for the field
+	StatusProvided FieldStatus = "provided" //value was supplied with the input, providers were not asked
 )
 
+// Input is a person to be enriched
+// CountryID is an optional ISO 3166-1 alpha-2 code that improves accuracy of age and gender for regional names
+// Age, Gender and Nationality are optional known values, providers are not asked about them
+type Input struct {
+	Name       string
+	Surname    string
+	Patronymic string
+	CountryID  string
+
+	Age         *int
+	Gender      *string
+	Nationality *string
+
+	//Surname and patronymic as they were spelled before normalization, rules that depend on the script use them
+	surnameOriginal    string
+	patronymicOriginal string
+}
+
 // Person struct represents person enriched with age, gender and nationality
+// Age, gender and nationality are nil if they are unknown
 type Person struct {
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	Name        string  `json:"name"`
+	Surname     string  `json:"surname"`
+	Patronymic  string  `json:"patronymic"`
+	Age         *int    `json:"age"`
+	Gender      *string `json:"gender"`
+	Nationality *string `json:"nationality"`
+
+	//Country that age and gender were localized to, empty if they were not
+	CountryHint string `json:"country_hint"`
+
+	//Confidence of the guesses
+	AgeSampleCount         int     `json:"age_sample_count"`
+	GenderProbability      float64 `json:"gender_probability"`
+	NationalityProbability float64 `json:"nationality_probability"`
+
+	//All countries returned by the nationality provider ranked by probability
+	NationalityCandidates []Country `json:"nationality_candidates"`
+
+	//How the enrichment of each field went
+	AgeStatus         FieldStatus `json:"age_status"`
+	GenderStatus      FieldStatus `json:"gender_status"`
+	NationalityStatus FieldStatus `json:"nationality_status"`
+
+	//Where the answer for each field came from, nil if no provider answered
+	AgeProvenance         *Provenance `json:"age_provenance"`
+	GenderProvenance      *Provenance `json:"gender_provenance"`
+	NationalityProvenance *Provenance `json:"nationality_provenance"`
+}
+
+// Provenance tells which provider answered for a field, when and how confident the answer was
+type Provenance struct {
+	Source      string    `json:"source"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Probability float64   `json:"probability,omitempty"`
+	SampleCount int       `json:"sample_count,omitempty"`
 }
 
 type Enricher interface {
-	EnrichPerson(ctx context.Context, name, surname, patronymic string) (*Person, error)
-	getNationality(ctx context.Context, name string) (string, error)
-	getAge(ctx context.Context, name string) (int, error)
-	getGender(ctx context.Context, name string) (string, error)
+	EnrichPerson(ctx context.Context, in Input) (*Person, error)
+	EnrichPeople(ctx context.Context, inputs []Input) ([]*Person, error)
 }
 
+// defaultEnricher asks the providers of each field in order until one of them has an answer
 type defaultEnricher struct {
-	client *http.Client
-	logger *zap.SugaredLogger
+	logger    *zap.SugaredLogger
+	providers Providers
+	opts      Options
 }
 
-func New(client *http.Client, logger *zap.SugaredLogger) Enricher {
-	return &defaultEnricher{client: client, logger: logger}
+// New returns an enricher that chains the providers with fallback in the order they are listed
+func New(logger *zap.SugaredLogger, providers Providers, opts Options) Enricher {
+	return &defaultEnricher{logger: logger, providers: providers, opts: opts}
 }
 
-// Enrich person enriches person struct with age, gender and nationality from APIs and returns enriched struct
-func (e *defaultEnricher) EnrichPerson(ctx context.Context, name, surname, patronymic string) (*Person, error) {
-	e.logger.Debugw("EnrichPerson called", "name", name, "surname", surname, "patronymic", patronymic)
+// NewAPIEnricher returns an enricher that uses only the agify, genderize and nationalize APIs
+func NewAPIEnricher(provider *APIProvider, logger *zap.SugaredLogger, opts Options) Enricher {
+	return New(logger, Providers{
+		Age:         []AgeProvider{provider},
+		Gender:      []GenderProvider{provider},
+		Nationality: []NationalityProvider{provider},
+	}, opts)
+}
 
-	//Get age
-	age, err := e.getAge(ctx, name)
-	if err != nil {
+// EnrichPerson enriches person struct with age, gender and nationality from providers and returns enriched struct
+// The lookups run concurrently and the first failure cancels the others
+// Name, surname and patronymic are normalized with NormalizeName before the lookups
+// If AllowPartial is set, failed lookups do not cancel the others and are reported in the field status instead
+// If in.CountryID is empty and LocalizeByNationality is set, nationality is looked up first and the most probable country is used as the hint
+// Fields known from the input are not looked up, known nationality is used as the hint instead
+func (e *defaultEnricher) EnrichPerson(ctx context.Context, in Input) (*Person, error) {
+	e.logger.Debugw("EnrichPerson called", "input", in)
+	in = normalizeInput(in)
+	if err := validateInput(in); err != nil {
 		return nil, err
 	}
-	e.logger.Debugw("Received age from API", "age", age)
 
-	//Get gender
-	gender, err := e.getGender(ctx, name)
-	if err != nil {
-		return nil, err
-	}
-	e.logger.Debugw("Received gender from API", "gender", gender)
+	//Create a group that cancels the remaining lookups as soon as one of them fails
+	g, ctx := newGroup(ctx)
+	var (
+		age         *AgeResponse
+		gender      *GenderResponse
+		nationality *NationalityResponse
+	)
 
-	//Get nationality
-	nationality, err := e.getNationality(ctx, name)
-	if err != nil {
-		return nil, err
+	//Use the known or the most probable nationality as the country hint
+	lookupNationality := in.Nationality == nil
+	if in.CountryID == "" && e.opts.LocalizeByNationality && in.Nationality != nil {
+		in.CountryID = *in.Nationality
+	}
+	if in.CountryID == "" && e.opts.LocalizeByNationality && lookupNationality {
+		var err error
+		lookupNationality = false
+		if nationality, err = e.nationality(ctx, in); err != nil {
+			if err := e.partial(ctx, "nationality", err); err != nil {
+				return nil, err
+			}
+		} else {
+			in.CountryID = nationality.Country[0].CountryID
+			e.logger.Debugw("Using nationality as country hint", "country_id", in.CountryID)
+		}
 	}
-	e.logger.Debugw("Received nationality from API", "nationality", nationality)
-
-	//Return the enriched person
-	return &Person{
-		Name:        name,
-		Surname:     surname,
-		Patronymic:  patronymic,
-		Age:         age,
-		Gender:      gender,
-		Nationality: nationality,
-	}, nil
-}
-
-type AgeResponse struct {
-	Count int    `json:"count"`
-	Name  string `json:"name"`
-	Age   int    `json:"age"`
-}
 
-// getAge makes a request to the agify API and returns most probable age
-func (e *defaultEnricher) getAge(ctx context.Context, name string) (int, error) {
-	//Add parameters to the URL
-	u, err := url.Parse(ageApiUrl)
-	if err != nil {
-		return 0, err
+	//Get age unless it is known
+	if in.Age == nil {
+		g.Go(func() error {
+			var err error
+			if age, err = e.age(ctx, in); err != nil {
+				return e.partial(ctx, "age", err)
+			}
+			e.logger.Debugw("Received age", "age", age.Age, "count", age.Count)
+			return nil
+		})
 	}
-	params := url.Values{}
-	params.Add("name", name)
-	u.RawQuery = params.Encode()
 
-	//Create a new request
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return 0, err
+	//Get gender unless it is known
+	if in.Gender == nil {
+		g.Go(func() error {
+			var err error
+			if gender, err = e.gender(ctx, in); err != nil {
+				return e.partial(ctx, "gender", err)
+			}
+			e.logger.Debugw("Received gender", "gender", gender.Gender, "probability", gender.Probability)
+			return nil
+		})
 	}
 
-	//Make the request
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return 0, err
+	//Get nationality unless it is known or was already looked up for the hint
+	if lookupNationality {
+		g.Go(func() error {
+			var err error
+			if nationality, err = e.nationality(ctx, in); err != nil {
+				return e.partial(ctx, "nationality", err)
+			}
+			e.logger.Debugw("Received nationality", "nationality", nationality.Country[0].CountryID, "probability", nationality.Country[0].Probability)
+			return nil
+		})
 	}
-	defer resp.Body.Close()
 
-	//Parse the response
-	var ageResponse AgeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ageResponse); err != nil {
-		return 0, err
+	//Wait for all lookups to finish
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
-	//Return the age
-	return ageResponse.Age, nil
+	//Return the enriched person
+	return e.buildPerson(in, age, gender, nationality), nil
 }
 
-type GenderResponse struct {
-	Count       int     `json:"count"`
-	Name        string  `json:"name"`
-	Gender      string  `json:"gender"`
-	Probability float64 `json:"probability"`
+// age asks age providers in order until one of them has an answer
+func (e *defaultEnricher) age(ctx context.Context, in Input) (*AgeResponse, error) {
+	return resolve(ctx, e.logger, "age", e.providers.Age, func(p AgeProvider) (*AgeResponse, error) {
+		return p.Age(ctx, in)
+	})
 }
 
-// getGender makes a request to the genderize API and returns the most probable gender
-func (e *defaultEnricher) getGender(ctx context.Context, name string) (string, error) {
-	//Add parameters to the URL
-	u, err := url.Parse(genderApiUrl)
-	if err != nil {
-		return "", err
-	}
-	params := url.Values{}
-	params.Add("name", name)
-	u.RawQuery = params.Encode()
+// gender asks gender providers in order until one of them has an answer
+func (e *defaultEnricher) gender(ctx context.Context, in Input) (*GenderResponse, error) {
+	return resolve(ctx, e.logger, "gender", e.providers.Gender, func(p GenderProvider) (*GenderResponse, error) {
+		return p.Gender(ctx, in)
+	})
+}
 
-	//Create a new request
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return "", err
-	}
+// nationality asks nationality providers in order until one of them returns at least one country
+func (e *defaultEnricher) nationality(ctx context.Context, in Input) (*NationalityResponse, error) {
+	return resolve(ctx, e.logger, "nationality", e.providers.Nationality, func(p NationalityProvider) (*NationalityResponse, error) {
+		nationality, err := p.Nationality(ctx, in)
+		if err == nil && len(nationality.Country) == 0 {
+			return nil, ErrNoResult
+		}
+		return nationality, err
+	})
+}
 
-	//Make the request
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+// resolve calls providers in order and returns the first answer with the name of the provider as its source
+// If every provider fails, the errors of all of them are returned
+func resolve[P any, R any](ctx context.Context, logger *zap.SugaredLogger, field string, providers []P, call func(P) (R, error)) (R, error) {
+	var zero R
+	var errs []error
+	for i, p := range providers {
+		result, err := call(p)
+		if err == nil {
+			if r, ok := any(result).(sourced); ok {
+				r.setSource(providerName(p, field))
+			}
+			return result, nil
+		}
 
-	//Parse the response
-	var genderResponse GenderResponse
-	if err := json.NewDecoder(resp.Body).Decode(&genderResponse); err != nil {
-		return "", err
+		//The caller is no longer waiting for the result
+		if ctx.Err() != nil {
+			return zero, err
+		}
+		logger.Debugw("Provider has no answer", "field", field, "provider", i, "error", err)
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return zero, fmt.Errorf("no %s providers: %w", field, ErrNoResult)
 	}
+	return zero, errors.Join(errs...)
+}
+
+// unresolvedKey marks contexts whose enrichment reports fields without an answer as failed
+type unresolvedKey struct{}
 
-	//Return the gender
-	return genderResponse.Gender, nil
+// AllowUnresolved returns a context that makes the enricher report fields no provider has an answer for as failed instead of
+// returning ErrNoResult, as if AllowPartial was set for them only
+// Failures of the providers are still returned
+func AllowUnresolved(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unresolvedKey{}, true)
 }
 
-type NationalityResponse struct {
-	Count   int    `json:"count"`
-	Name    string `json:"name"`
-	Country []struct {
-		CountryID   string  `json:"country_id"`
-		Probability float64 `json:"probability"`
-	} `json:"country"`
+// partial returns nil if AllowPartial is set so that the field is left unresolved instead of failing the enrichment
+// Without AllowPartial the field is left unresolved only if the context allows it and no provider had an answer
+// Cancellation of the request is always returned
+func (e *defaultEnricher) partial(ctx context.Context, field string, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	unresolved, _ := ctx.Value(unresolvedKey{}).(bool)
+	if !e.opts.AllowPartial && !(unresolved && noResult(err)) {
+		return err
+	}
+	e.logger.Warnw("Field is left unresolved", "field", field, "error", err)
+	return nil
 }
 
-// getNationality makes a request to the nationalize API and returns the most probable nationality
-func (e *defaultEnricher) getNationality(ctx context.Context, name string) (string, error) {
-	// Add parameters to the URL
-	u, err := url.Parse(nationalityApiUrl)
-	if err != nil {
-		return "", err
+// noResult reports whether err only tells that providers had no answer, every joined error has to be ErrNoResult
+func noResult(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			if !noResult(err) {
+				return false
+			}
+		}
+		return true
 	}
-	params := url.Values{}
-	params.Add("name", name)
-	u.RawQuery = params.Encode()
+	return errors.Is(err, ErrNoResult)
+}
 
-	// Create a new request
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return "", err
+// buildPerson combines provider answers into the enriched person, nil answers are reported as failed or skipped
+// unless the field is known from the input
+func (e *defaultEnricher) buildPerson(in Input, age *AgeResponse, gender *GenderResponse, nationality *NationalityResponse) *Person {
+	person := &Person{
+		Name:              in.Name,
+		Surname:           in.Surname,
+		Patronymic:        in.Patronymic,
+		CountryHint:       in.CountryID,
+		AgeStatus:         fieldStatus(age != nil, len(e.providers.Age)),
+		GenderStatus:      fieldStatus(gender != nil, len(e.providers.Gender)),
+		NationalityStatus: fieldStatus(nationality != nil, len(e.providers.Nationality)),
+	}
+	fetchedAt := time.Now()
+	if age != nil {
+		person.AgeSampleCount = age.Count
+		person.AgeProvenance = &Provenance{Source: age.Source, FetchedAt: fetchedAt, SampleCount: age.Count}
 	}
+	if gender != nil {
+		person.GenderProbability = gender.Probability
+		person.GenderProvenance = &Provenance{Source: gender.Source, FetchedAt: fetchedAt, Probability: gender.Probability, SampleCount: gender.Count}
+	}
+	if nationality != nil {
+		person.NationalityProbability = nationality.Country[0].Probability
+		person.NationalityCandidates = nationality.Country
+		person.NationalityProvenance = &Provenance{Source: nationality.Source, FetchedAt: fetchedAt, Probability: nationality.Country[0].Probability, SampleCount: nationality.Count}
+	}
+	e.applyThresholds(person, age, gender, nationality)
+	applyKnown(person, in)
+	return person
+}
 
-	// Make the request
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return "", err
+// applyKnown sets the fields known from the input and marks them as provided, no provider answered for them
+func applyKnown(person *Person, in Input) {
+	if in.Age != nil {
+		person.Age, person.AgeStatus, person.AgeProvenance = in.Age, StatusProvided, nil
 	}
-	defer resp.Body.Close()
+	if in.Gender != nil {
+		person.Gender, person.GenderStatus, person.GenderProvenance = in.Gender, StatusProvided, nil
+	}
+	if in.Nationality != nil {
+		person.Nationality, person.NationalityStatus, person.NationalityProvenance = in.Nationality, StatusProvided, nil
+	}
+}
 
-	// Parse the response
-	var nationalityResponse NationalityResponse
-	if err := json.NewDecoder(resp.Body).Decode(&nationalityResponse); err != nil {
-		return "", err
+// fieldStatus returns status of the field depending on whether it resolved and how many providers it has
+func fieldStatus(resolved bool, providers int) FieldStatus {
+	switch {
+	case resolved:
+		return StatusOK
+	case providers == 0:
+		return StatusSkipped
+	default:
+		return StatusFailed
 	}
+}
 
-	// Return the nationality
-	if len(nationalityResponse.Country) > 0 {
-		return nationalityResponse.Country[0].CountryID, nil
+// applyThresholds sets age, gender and nationality of the person only if the guesses are confident enough
+func (e *defaultEnricher) applyThresholds(person *Person, age *AgeResponse, gender *GenderResponse, nationality *NationalityResponse) {
+	if age != nil && (age.Uncounted || age.Count > 0 && age.Count >= e.opts.MinAgeSampleCount) {
+		person.Age = &age.Age
+	} else if age != nil {
+		e.logger.Debugw("Age is left unknown", "name", person.Name, "count", age.Count)
+	}
+	if gender != nil && gender.Gender != "" && gender.Probability >= e.opts.MinGenderProbability {
+		person.Gender = &gender.Gender
+	} else if gender != nil {
+		e.logger.Debugw("Gender is left unknown", "name", person.Name, "probability", gender.Probability)
+	}
+	if nationality != nil && nationality.Country[0].Probability >= e.opts.MinNationalityProbability {
+		person.Nationality = &nationality.Country[0].CountryID
+	} else if nationality != nil {
+		e.logger.Debugw("Nationality is left unknown", "name", person.Name, "probability", nationality.Country[0].Probability)
 	}
-	return "", errors.New("Empty response from nationalize API")
 }
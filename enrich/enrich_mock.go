@@ -6,21 +6,21 @@ func NewMockEnricher() Enricher {
 	return &MockEnricher{}
 }
 
+// MockEnricher does not know any name, fields known from the input are returned as provided
 type MockEnricher struct {
 }
 
-func (e *MockEnricher) EnrichPerson(ctx context.Context, name, surname, patronymic string) (*Person, error) {
-	return &Person{}, nil
+func (e *MockEnricher) EnrichPerson(ctx context.Context, in Input) (*Person, error) {
+	person := &Person{AgeStatus: StatusOK, GenderStatus: StatusOK, NationalityStatus: StatusOK}
+	applyKnown(person, in)
+	return person, nil
 }
 
-func (e *MockEnricher) getNationality(ctx context.Context, name string) (string, error) {
-	return "", nil
-}
-
-func (e *MockEnricher) getAge(ctx context.Context, name string) (int, error) {
-	return 0, nil
-}
-
-func (e *MockEnricher) getGender(ctx context.Context, name string) (string, error) {
-	return "", nil
+func (e *MockEnricher) EnrichPeople(ctx context.Context, inputs []Input) ([]*Person, error) {
+	people := make([]*Person, len(inputs))
+	for i, in := range inputs {
+		people[i] = &Person{AgeStatus: StatusOK, GenderStatus: StatusOK, NationalityStatus: StatusOK}
+		applyKnown(people[i], in)
+	}
+	return people, nil
 }
@@ -4,80 +4,523 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dafraer/effective-mobile-task/errkind"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
 const defaultTimeOut = time.Second * 5
 
-func TestEnrichPerson(t *testing.T) {
-
-	name := "Ivan"
-	surname := "Ivanov"
-	patronymic := "Ivanovich"
+// newTestProvider creates an API provider that queries fake servers instead of the public APIs
+func newTestProvider(t *testing.T) *APIProvider {
 	//Create logger
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic(fmt.Errorf("error while creating new Logger, %v ", err))
 	}
 
-	enricher := New(&http.Client{Timeout: defaultTimeOut}, logger.Sugar())
-	person, err := enricher.EnrichPerson(context.Background(), name, surname, patronymic)
+	//Create fake providers
+	ageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"count":1000,"name":%q,"age":42}`, r.URL.Query().Get("name"))
+	}))
+	genderServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"count":1000,"name":%q,"gender":"male","probability":0.99}`, r.URL.Query().Get("name"))
+	}))
+	nationalityServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"count":1000,"name":%q,"country":[{"country_id":"RU","probability":0.4},{"country_id":"UA","probability":0.2}]}`, r.URL.Query().Get("name"))
+	}))
+	t.Cleanup(ageServer.Close)
+	t.Cleanup(genderServer.Close)
+	t.Cleanup(nationalityServer.Close)
+
+	return NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		GenderApiUrl:      genderServer.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+}
+
+// newTestEnricher creates an enricher that uses only the test API provider
+func newTestEnricher(t *testing.T) Enricher {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+	return NewAPIEnricher(newTestProvider(t), logger.Sugar(), Options{})
+}
+
+func TestEnrichPerson(t *testing.T) {
+	name := "Ivan"
+	surname := "Ivanov"
+	patronymic := "Ivanovich"
+
+	enricher := newTestEnricher(t)
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: name, Surname: surname, Patronymic: patronymic})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, person)
 	assert.Equal(t, name, person.Name)
 	assert.Equal(t, surname, person.Surname)
 	assert.Equal(t, patronymic, person.Patronymic)
-	assert.NotEmpty(t, person.Age)
-	assert.NotEmpty(t, person.Gender)
-	assert.NotEmpty(t, person.Nationality)
+	assert.Equal(t, ptr(42), person.Age)
+	assert.Equal(t, ptr("male"), person.Gender)
+	assert.Equal(t, ptr("RU"), person.Nationality)
+	assert.Equal(t, 1000, person.AgeSampleCount)
+	assert.Equal(t, 0.99, person.GenderProbability)
+	assert.Equal(t, 0.4, person.NationalityProbability)
+}
+
+func TestEnrichPersonCancelsOnFailure(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Nationality provider fails immediately while the others hang until the request is cancelled
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":0,"name":"Ivan","country":[]}`)
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(broken.Close)
+
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         slow.URL,
+		GenderApiUrl:      slow.URL,
+		NationalityApiUrl: broken.URL,
+	})
+	enricher := NewAPIEnricher(provider, logger.Sugar(), Options{})
+
+	start := time.Now()
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", Patronymic: "Ivanovich"})
+	assert.Error(t, err)
+	assert.Nil(t, person)
+	assert.Less(t, time.Since(start), defaultTimeOut)
 }
 
 func TestGetAge(t *testing.T) {
 	name := "Ivan"
 
+	provider := newTestProvider(t)
+	age, err := provider.Age(context.Background(), Input{Name: name})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, age.Age)
+	assert.Equal(t, 1000, age.Count)
+}
+
+func TestGetGender(t *testing.T) {
+	name := "Ivan"
+
+	provider := newTestProvider(t)
+	gender, err := provider.Gender(context.Background(), Input{Name: name})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "male", gender.Gender)
+	assert.Equal(t, 0.99, gender.Probability)
+}
+
+func TestGetNationality(t *testing.T) {
+	name := "Ivan"
+
+	provider := newTestProvider(t)
+	nationality, err := provider.Nationality(context.Background(), Input{Name: name})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "RU", nationality.Country[0].CountryID)
+	assert.Equal(t, []Country{{CountryID: "RU", Probability: 0.4}, {CountryID: "UA", Probability: 0.2}}, nationality.Country)
+}
+
+func TestRateLimit(t *testing.T) {
 	//Create logger
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic(fmt.Errorf("error while creating new Logger, %v ", err))
 	}
-	enricher := New(&http.Client{Timeout: defaultTimeOut}, logger.Sugar())
-	age, err := enricher.getAge(context.Background(), name)
 
+	//Provider allows a single request and then responds with 429
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		w.Header().Set("X-Rate-Limit-Reset", "60")
+		if calls > 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":"Request limit reached"}`)
+			return
+		}
+		w.Header().Set("X-Rate-Limit-Remaining", "0")
+		fmt.Fprint(w, `{"count":1000,"name":"Ivan","age":42}`)
+	}))
+	t.Cleanup(server.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{AgeApiUrl: server.URL})
+
+	//First request succeeds, but exhausts the quota
+	age, err := provider.Age(context.Background(), Input{Name: "Ivan"})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, age)
+	assert.Equal(t, 42, age.Age)
+
+	//Second request fails without calling the provider
+	_, err = provider.Age(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrRateLimited)
+	var rateLimitErr *RateLimitError
+	assert.ErrorAs(t, err, &rateLimitErr)
+	assert.Equal(t, "agify", rateLimitErr.Provider)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), rateLimitErr.Reset, time.Second*5)
+	assert.Equal(t, 1, calls)
 }
 
-func TestGetGender(t *testing.T) {
-	name := "Ivan"
+func TestStatusError(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{GenderApiUrl: server.URL})
+
+	_, err = provider.Gender(context.Background(), Input{Name: "Ivan"})
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	assert.ErrorIs(t, err, errkind.ErrUpstream)
+
+	//Unreachable provider is an upstream failure too
+	server.Close()
+	_, err = provider.Gender(context.Background(), Input{Name: "Ivan"})
+	var providerErr *ProviderError
+	assert.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, "genderize", providerErr.Provider)
+	assert.ErrorIs(t, err, errkind.ErrUpstream)
+}
+
+func TestInvalidInput(t *testing.T) {
+	//Providers are not asked about invalid inputs
+	enricher := newTestEnricher(t)
+	for _, in := range []Input{{Name: " "}, {Name: "Ivan", CountryID: "Ukraine"}} {
+		_, err := enricher.EnrichPerson(context.Background(), in)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.ErrorIs(t, err, errkind.ErrValidation)
+		_, err = enricher.EnrichPeople(context.Background(), []Input{{Name: "Maria"}, in})
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.ErrorContains(t, err, "input 1")
+	}
+}
+
+func TestInvalidResponses(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
 
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		reason      string
+	}{
+		{"html page", "text/html", "<html>Bad gateway</html>", "unexpected content type"},
+		{"too large", "application/json", `{"count":1,"name":"` + strings.Repeat("a", 2048) + `","age":30}`, "exceeds"},
+		{"malformed json", "application/json", `{"count":1,"name":`, "malformed json"},
+		{"wrong type", "application/json", `{"count":1,"name":"Ivan","age":"thirty"}`, "malformed json"},
+		{"trailing data", "application/json", `{"count":1,"name":"Ivan","age":30} garbage`, "unexpected data"},
+		{"age out of range", "application/json", `{"count":1,"name":"Ivan","age":1000}`, "age 1000 is out of range"},
+		{"negative count", "application/json", `{"count":-1,"name":"Ivan","age":30}`, "negative count"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				fmt.Fprint(w, tc.body)
+			}))
+			t.Cleanup(server.Close)
+			provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{AgeApiUrl: server.URL, MaxResponseSize: 1024})
+
+			_, err := provider.Age(context.Background(), Input{Name: "Ivan"})
+			assert.ErrorIs(t, err, ErrInvalidResponse)
+			var decodeErr *DecodeError
+			assert.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, "agify", decodeErr.Provider)
+			assert.Contains(t, decodeErr.Error(), tc.reason)
+		})
+	}
+
+	//Values outside of the allowed sets are rejected in single and batched responses
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if r.URL.Query().Has("name[]") {
+			fmt.Fprint(w, `[{"count":1,"name":"Ivan","gender":"male","probability":0.9},{"count":1,"name":"Olga","gender":"female","probability":1.5}]`)
+			return
+		}
+		fmt.Fprint(w, `{"count":1,"name":"Ivan","gender":"robot","probability":0.9}`)
+	}))
+	t.Cleanup(server.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{GenderApiUrl: server.URL})
+	_, err = provider.Gender(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	assert.ErrorContains(t, err, `unknown gender "robot"`)
+	_, err = provider.Genders(context.Background(), []Input{{Name: "Ivan"}, {Name: "Olga"}})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	assert.ErrorContains(t, err, "result 1: gender probability 1.5 is out of range")
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEnrichPersonThresholds(t *testing.T) {
 	//Create logger
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic(fmt.Errorf("error while creating new Logger, %v ", err))
 	}
-	enricher := New(&http.Client{Timeout: defaultTimeOut}, logger.Sugar())
-	gender, err := enricher.getGender(context.Background(), name)
 
+	//Providers return guesses with low confidence
+	ageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":0,"name":"Xyz","age":null}`)
+	}))
+	genderServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":10,"name":"Xyz","gender":"male","probability":0.51}`)
+	}))
+	nationalityServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":10,"name":"Xyz","country":[{"country_id":"RU","probability":0.6}]}`)
+	}))
+	t.Cleanup(ageServer.Close)
+	t.Cleanup(genderServer.Close)
+	t.Cleanup(nationalityServer.Close)
+
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		GenderApiUrl:      genderServer.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	enricher := NewAPIEnricher(provider, logger.Sugar(), Options{
+		MinGenderProbability:      0.8,
+		MinNationalityProbability: 0.5,
+	})
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Xyz", Surname: "Xyzov", Patronymic: ""})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, gender)
+
+	//Age and gender are unknown, nationality is confident enough
+	assert.Nil(t, person.Age)
+	assert.Nil(t, person.Gender)
+	assert.Equal(t, ptr("RU"), person.Nationality)
+	assert.Equal(t, 0.51, person.GenderProbability)
 }
 
-func TestGetNationality(t *testing.T) {
-	name := "Ivan"
+func TestEnrichPersonCountryHint(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Age and gender providers answer differently for localized requests
+	ageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("country_id") == "UA" {
+			fmt.Fprint(w, `{"count":100,"name":"Ivan","age":35,"country_id":"UA"}`)
+			return
+		}
+		fmt.Fprint(w, `{"count":1000,"name":"Ivan","age":42}`)
+	}))
+	genderServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, r.URL.Query().Get("country_id"), "UA")
+		fmt.Fprint(w, `{"count":100,"name":"Ivan","gender":"male","probability":1,"country_id":"UA"}`)
+	}))
+	nationalityServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Empty(t, r.URL.Query().Get("country_id"))
+		fmt.Fprint(w, `{"count":1000,"name":"Ivan","country":[{"country_id":"UA","probability":0.4},{"country_id":"RU","probability":0.3}]}`)
+	}))
+	t.Cleanup(ageServer.Close)
+	t.Cleanup(genderServer.Close)
+	t.Cleanup(nationalityServer.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		GenderApiUrl:      genderServer.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	opts := Options{}
+
+	//Explicit hint is passed to age and gender providers
+	enricher := NewAPIEnricher(provider, logger.Sugar(), opts)
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov", CountryID: "UA"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(35), person.Age)
+	assert.Equal(t, "UA", person.CountryHint)
+
+	//Most probable nationality is used as the hint
+	opts.LocalizeByNationality = true
+	enricher = NewAPIEnricher(provider, logger.Sugar(), opts)
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Surname: "Ivanov"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(35), person.Age)
+	assert.Equal(t, ptr("UA"), person.Nationality)
+	assert.Equal(t, "UA", person.CountryHint)
+}
+
+// staticGender is a gender provider that knows a fixed set of names
+type staticGender map[string]string
+
+func (g staticGender) Gender(ctx context.Context, in Input) (*GenderResponse, error) {
+	gender, ok := g[in.Name]
+	if !ok {
+		return nil, ErrNoResult
+	}
+	return &GenderResponse{Name: in.Name, Gender: gender, Probability: 1}, nil
+}
+
+func TestEnrichPersonFallback(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Custom provider is asked first and the API is used for names it does not know
+	provider := newTestProvider(t)
+	enricher := New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{provider},
+		Gender:      []GenderProvider{staticGender{"Maria": "female"}, provider},
+		Nationality: []NationalityProvider{provider},
+	}, Options{})
+
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Maria"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("female"), person.Gender)
+	assert.Equal(t, 1.0, person.GenderProbability)
+	assert.Equal(t, "enrich.staticGender", person.GenderProvenance.Source)
+
+	//Provenance records the API that answered for each field
+	person, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr("male"), person.Gender)
+	assert.Equal(t, 0.99, person.GenderProbability)
+	assert.Equal(t, "genderize", person.GenderProvenance.Source)
+	assert.Equal(t, 0.99, person.GenderProvenance.Probability)
+	assert.Equal(t, "agify", person.AgeProvenance.Source)
+	assert.Equal(t, 1000, person.AgeProvenance.SampleCount)
+	assert.Equal(t, "nationalize", person.NationalityProvenance.Source)
+	assert.WithinDuration(t, time.Now(), person.NationalityProvenance.FetchedAt, time.Minute)
+
+	//Field without any provider that knows the name cannot be resolved
+	enricher = New(logger.Sugar(), Providers{
+		Age:         []AgeProvider{provider},
+		Gender:      []GenderProvider{staticGender{}},
+		Nationality: []NationalityProvider{provider},
+	}, Options{})
+	_, err = enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrNoResult)
+}
+
+func TestEnrichPersonPartial(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	//Nationality provider does not know the name
+	ageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":1000,"name":"Ivan","age":42}`)
+	}))
+	nationalityServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count":0,"name":"Ivan","country":[]}`)
+	}))
+	t.Cleanup(ageServer.Close)
+	t.Cleanup(nationalityServer.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		NationalityApiUrl: nationalityServer.URL,
+	})
+	providers := Providers{
+		Age:         []AgeProvider{provider},
+		Nationality: []NationalityProvider{provider},
+	}
+
+	//Without AllowPartial the whole enrichment fails
+	_, err = New(logger.Sugar(), providers, Options{}).EnrichPerson(context.Background(), Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, ErrNoResult)
+
+	//With AllowPartial the resolved fields are returned with the status of each field
+	for _, localize := range []bool{false, true} {
+		enricher := New(logger.Sugar(), providers, Options{AllowPartial: true, LocalizeByNationality: localize})
+		person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan"})
+		assert.NoError(t, err)
+		assert.Equal(t, ptr(42), person.Age)
+		assert.Nil(t, person.Gender)
+		assert.Nil(t, person.Nationality)
+		assert.Equal(t, StatusOK, person.AgeStatus)
+		assert.Equal(t, StatusSkipped, person.GenderStatus)
+		assert.Equal(t, StatusFailed, person.NationalityStatus)
+		assert.Empty(t, person.CountryHint)
+	}
+
+	//Cancellation is not hidden by AllowPartial
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New(logger.Sugar(), providers, Options{AllowPartial: true}).EnrichPerson(ctx, Input{Name: "Ivan"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestEnrichPersonKnownFields(t *testing.T) {
 	//Create logger
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic(fmt.Errorf("error while creating new Logger, %v ", err))
 	}
-	enricher := New(&http.Client{Timeout: defaultTimeOut}, logger.Sugar())
 
-	nationality, err := enricher.getNationality(context.Background(), name)
+	//Known fields are not looked up and known nationality is used as the hint
+	ageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "UA", r.URL.Query().Get("country_id"))
+		fmt.Fprint(w, `{"count":100,"name":"Ivan","age":35,"country_id":"UA"}`)
+	}))
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}))
+	t.Cleanup(ageServer.Close)
+	t.Cleanup(failServer.Close)
+	provider := NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{
+		AgeApiUrl:         ageServer.URL,
+		GenderApiUrl:      failServer.URL,
+		NationalityApiUrl: failServer.URL,
+	})
+	enricher := NewAPIEnricher(provider, logger.Sugar(), Options{LocalizeByNationality: true})
 
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Ivan", Gender: ptr("male"), Nationality: ptr("UA")})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, nationality)
+	assert.Equal(t, ptr(35), person.Age)
+	assert.Equal(t, StatusOK, person.AgeStatus)
+	assert.Equal(t, ptr("male"), person.Gender)
+	assert.Equal(t, StatusProvided, person.GenderStatus)
+	assert.Nil(t, person.GenderProvenance)
+	assert.Equal(t, "agify", person.AgeProvenance.Source)
+	assert.Equal(t, ptr("UA"), person.Nationality)
+	assert.Equal(t, StatusProvided, person.NationalityStatus)
+	assert.Equal(t, "UA", person.CountryHint)
 }
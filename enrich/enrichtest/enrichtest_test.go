@@ -0,0 +1,84 @@
+package enrichtest
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+
+	//Responses are recorded, the apikey is not saved
+	recorder := NewRecorder(dir, Record, nil)
+	resp, err := recorder.Client().Get(server.URL + AgePath + "?name=Ivan&apikey=secret")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.JSONEq(t, `{"count":1000,"name":"Ivan","age":24}`, string(body))
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	data, err := os.ReadFile(dir + "/" + files[0].Name())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	//Recorded responses are replayed without the server, with any apikey
+	server.Close()
+	recorder = NewRecorder(dir, Replay, nil)
+	resp, err = recorder.Client().Get(server.URL + AgePath + "?name=Ivan&apikey=other")
+	assert.NoError(t, err)
+	replayed, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, body, replayed)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	//Requests that were not recorded fail
+	_, err = recorder.Client().Get(server.URL + AgePath + "?name=Maria")
+	assert.ErrorIs(t, err, ErrNoFixture)
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	server.Set("Limited", RateLimited)
+	server.Set("Broken", Malformed)
+	server.Set("Nobody", Empty)
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get(GenderPath + "?name=Anna&country_id=UA")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"count":1000,"name":"Anna","gender":"female","probability":0.99,"country_id":"UA"}`, body)
+
+	code, body = get(NationalityPath + "?name[]=Ivan&name[]=Nobody")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[{"count":1000,"name":"Ivan","country":[{"country_id":"RU","probability":0.5},{"country_id":"UA","probability":0.2}]},
+	{"count":0,"name":"Nobody","country":[]}]`, body)
+
+	code, _ = get(AgePath + "?name[]=Ivan&name[]=Limited")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	code, body = get(AgePath + "?name=Broken")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"count":10,"name":`, body)
+
+	code, _ = get("/unknown?name=Ivan")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, 2, server.Requests(AgePath))
+}
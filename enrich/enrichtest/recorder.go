@@ -0,0 +1,154 @@
+// Package enrichtest provides tools for testing code that calls the enrichment APIs without depending on the live services
+package enrichtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode tells the recorder whether to replay saved responses or to record new ones
+type Mode int
+
+const (
+	Replay Mode = iota //responses are read from the fixtures, missing fixtures are errors
+	Record             //requests are sent to the real transport and the responses are saved as fixtures
+)
+
+// RecordEnv is the environment variable that switches recorders created with ModeFromEnv to recording
+const RecordEnv = "ENRICH_RECORD"
+
+// ModeFromEnv returns Record if RecordEnv is set to a non-empty value and Replay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return Record
+	}
+	return Replay
+}
+
+// ErrNoFixture is returned in replay mode for requests that have not been recorded
+var ErrNoFixture = errors.New("no recorded response")
+
+// Recorder is an http.RoundTripper that records responses to fixture files and replays them deterministically
+// Each request is stored in its own file named after the host and a hash of the method and the URL,
+// the apikey query parameter is left out of both the hash and the saved URL
+type Recorder struct {
+	dir  string
+	mode Mode
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+// fixture is a recorded response as it is saved on disk
+type fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// NewRecorder returns a recorder that keeps fixtures in dir, next is used to make real requests while recording
+// If next is nil http.DefaultTransport is used
+func NewRecorder(dir string, mode Mode, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, mode: mode, next: next}
+}
+
+// Client returns an http client that uses the recorder as its transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip replays the recorded response to the request or records a new one depending on the mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(r.dir, fixtureName(req))
+	if r.mode == Record {
+		return r.record(req, path)
+	}
+
+	//Read the recorded response
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s %s: %w, run the tests with %s=1 to record it", req.Method, redactedURL(req), ErrNoFixture, RecordEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error decoding fixture %s: %v", path, err)
+	}
+	return f.response(req), nil
+}
+
+// record makes the real request and saves the response to path
+func (r *Recorder) record(req *http.Request, path string) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	//Save the response
+	f := fixture{Method: req.Method, URL: redactedURL(req), StatusCode: resp.StatusCode, Header: resp.Header, Body: string(body)}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return f.response(req), nil
+}
+
+// response builds the http response from the fixture
+func (f *fixture) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(f.Body))),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}
+}
+
+// fixtureName returns the file name of the fixture for the request
+func fixtureName(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + redactedURL(req)))
+	host := strings.NewReplacer(":", "_", ".", "_").Replace(req.URL.Host)
+	return host + "-" + hex.EncodeToString(sum[:6]) + ".json"
+}
+
+// redactedURL returns the URL of the request without the apikey query parameter
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	query.Del("apikey")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
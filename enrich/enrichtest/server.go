@@ -0,0 +1,160 @@
+package enrichtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+)
+
+// Scenario is how the fake provider answers for a name
+type Scenario int
+
+const (
+	Success     Scenario = iota //answers with a deterministic guess for the name
+	Empty                       //answers that the name is unknown: null age and gender, no countries
+	RateLimited                 //responds with 429 and X-Rate-Limit-Reset
+	Malformed                   //responds with 200 and truncated json
+)
+
+// Paths of the fake APIs on the server
+const (
+	AgePath         = "/agify"
+	GenderPath      = "/genderize"
+	NationalityPath = "/nationalize"
+)
+
+// Server is a fake agify, genderize and nationalize API served by a single httptest server
+// Names answer with Success unless another scenario is set for them, a batched request fails as a whole if any of its names
+// is RateLimited or Malformed
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	scenarios map[string]Scenario
+	requests  map[string]int
+}
+
+// NewServer starts a fake provider server, it has to be closed by the caller
+func NewServer() *Server {
+	s := &Server{scenarios: make(map[string]Scenario), requests: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Set sets the scenario for the name
+func (s *Server) Set(name string, scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenarios[name] = scenario
+}
+
+// Requests returns the number of requests made to the API at path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Options returns API options that point the enrichment API provider to the server
+func (s *Server) Options() enrich.APIOptions {
+	return enrich.APIOptions{
+		AgeApiUrl:         s.URL + AgePath,
+		GenderApiUrl:      s.URL + GenderPath,
+		NationalityApiUrl: s.URL + NationalityPath,
+	}
+}
+
+// handle answers requests for a single name and batched requests like the real APIs do
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != AgePath && r.URL.Path != GenderPath && r.URL.Path != NationalityPath {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	names, batched := query["name[]"]
+	if !batched {
+		names = query["name"]
+	}
+	if len(names) == 0 {
+		http.Error(w, `{"error":"Missing 'name' parameter"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	//Find the scenario of each name, errors fail the whole request
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	scenarios := make([]Scenario, len(names))
+	failure := Success
+	for i, name := range names {
+		scenarios[i] = s.scenarios[name]
+		if failure == Success && (scenarios[i] == RateLimited || scenarios[i] == Malformed) {
+			failure = scenarios[i]
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch failure {
+	case RateLimited:
+		w.Header().Set("X-Rate-Limit-Limit", "100")
+		w.Header().Set("X-Rate-Limit-Remaining", "0")
+		w.Header().Set("X-Rate-Limit-Reset", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":"Request limit reached"}`)
+		return
+	case Malformed:
+		fmt.Fprint(w, `{"count":10,"name":`)
+		return
+	}
+
+	//Answer for each name
+	countryID := query.Get("country_id")
+	answers := make([]any, len(names))
+	for i, name := range names {
+		answers[i] = answer(r.URL.Path, name, countryID, scenarios[i] == Empty)
+	}
+	if batched {
+		json.NewEncoder(w).Encode(answers)
+		return
+	}
+	json.NewEncoder(w).Encode(answers[0])
+}
+
+// answer returns the response of the API at path for the name
+// Age is 20 plus the length of the name, names ending with "a" are female and nationality is RU or UA
+func answer(path, name, countryID string, empty bool) map[string]any {
+	response := map[string]any{"count": 1000, "name": name}
+	if countryID != "" {
+		response["country_id"] = countryID
+	}
+	if empty {
+		response["count"] = 0
+	}
+
+	switch path {
+	case AgePath:
+		response["age"] = nil
+		if !empty {
+			response["age"] = 20 + len([]rune(name))
+		}
+	case GenderPath:
+		response["gender"], response["probability"] = nil, 0.0
+		if !empty {
+			response["gender"], response["probability"] = "male", 0.99
+			if strings.HasSuffix(name, "a") {
+				response["gender"] = "female"
+			}
+		}
+	case NationalityPath:
+		response["country"] = []enrich.Country{}
+		if !empty {
+			response["country"] = []enrich.Country{{CountryID: "RU", Probability: 0.5}, {CountryID: "UA", Probability: 0.2}}
+		}
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/errkind"
+)
+
+// Provider errors match errkind.ErrUpstream, invalid inputs match errkind.ErrValidation
+
+// ErrInvalidInput is returned when the input cannot be enriched, e.g. the name is empty
+var ErrInvalidInput = errkind.New(errkind.ErrValidation, "invalid input", nil)
+
+// ErrRateLimited is returned when the provider quota is exhausted
+var ErrRateLimited = errors.New("provider rate limit exceeded")
+
+// RateLimitError is returned when the provider responds with 429 or its quota is known to be exhausted
+// Reset is zero if the provider did not say when the quota resets
+type RateLimitError struct {
+	Provider string
+	Reset    time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s: %v", e.Provider, ErrRateLimited)
+	}
+	return fmt.Sprintf("%s: %v until %s", e.Provider, ErrRateLimited, e.Reset.Format(time.RFC3339))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited || target == errkind.ErrUpstream
+}
+
+// StatusError is returned when the provider responds with a non-2xx status code
+type StatusError struct {
+	Provider   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.Provider, e.StatusCode)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == errkind.ErrUpstream
+}
+
+// ErrCircuitOpen is returned when the provider is considered unhealthy and requests to it are rejected
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+// CircuitOpenError is returned instead of calling the provider while its circuit breaker is open
+type CircuitOpenError struct {
+	Provider string
+	Until    time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, ErrCircuitOpen)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen || target == errkind.ErrUpstream
+}
+
+// ErrInvalidResponse is returned when the provider response cannot be decoded or contains invalid values
+var ErrInvalidResponse = errors.New("invalid provider response")
+
+// DecodeError is returned when the provider response is too large, is not json, cannot be decoded or does not pass validation
+// Err is the underlying decoding error, it is nil if the response was decoded but contains invalid values
+type DecodeError struct {
+	Provider string
+	Reason   string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %v: %s", e.Provider, ErrInvalidResponse, e.Reason)
+	}
+	return fmt.Sprintf("%s: %v: %s: %v", e.Provider, ErrInvalidResponse, e.Reason, e.Err)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrInvalidResponse || target == errkind.ErrUpstream
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError is returned when the provider cannot be reached, e.g. the connection is refused or the request times out
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e *ProviderError) Is(target error) bool {
+	return target == errkind.ErrUpstream
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,42 @@
+package enrich
+
+import (
+	"context"
+	"sync"
+)
+
+// group runs functions concurrently and cancels the shared context as soon as one of them fails
+type group struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+// newGroup returns a group and a context that is cancelled when a function fails or Wait returns
+func newGroup(ctx context.Context) (*group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{cancel: cancel}, ctx
+}
+
+// Go runs f in a new goroutine
+func (g *group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			//Record the first error and cancel the remaining functions
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait waits for all functions to finish and returns the first error
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,211 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// endpoint is a single enrichment API together with its rate limit state
+type endpoint struct {
+	name    string
+	url     string
+	timeout time.Duration
+	breaker *breaker
+
+	mu             sync.Mutex
+	exhaustedUntil time.Time
+}
+
+func newEndpoint(name, url string, timeout time.Duration, breaker BreakerOptions, logger *zap.SugaredLogger) *endpoint {
+	return &endpoint{
+		name:    name,
+		url:     url,
+		timeout: timeout,
+		breaker: newBreaker(name, breaker, logger),
+	}
+}
+
+// get makes a GET request to the endpoint with the given query parameters and decodes the json response into v
+// Transient failures are retried according to the retry policy
+// Requests fail fast while the circuit breaker of the endpoint is open
+func (p *APIProvider) get(ctx context.Context, ep *endpoint, params url.Values, v any) error {
+	if err := ep.breaker.allow(); err != nil {
+		return err
+	}
+	err := p.retry(ctx, ep, params, v)
+
+	//Cancelled requests and exhausted quota say nothing about provider health
+	if ctx.Err() != nil || errors.Is(err, ErrRateLimited) {
+		ep.breaker.release()
+		return err
+	}
+	ep.breaker.record(err == nil)
+	return err
+}
+
+// retry makes requests to the endpoint until one succeeds or the retry policy gives up
+func (p *APIProvider) retry(ctx context.Context, ep *endpoint, params url.Values, v any) error {
+	policy := p.opts.Retry
+	for attempt := 1; ; attempt++ {
+		err := p.do(ctx, ep, params, v)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(ctx, err) {
+			return err
+		}
+
+		//Do not start the wait if the next attempt would not fit before the deadline
+		backoff := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return err
+		}
+		p.logger.Warnw("Retrying request to provider", "provider", ep.name, "attempt", attempt, "backoff", backoff, "error", err)
+
+		//Wait for the backoff to pass or the context to be done
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// do makes a single request to the endpoint
+func (p *APIProvider) do(ctx context.Context, ep *endpoint, params url.Values, v any) error {
+	//Do not call the provider until its quota is reset
+	if reset, ok := ep.exhausted(); ok {
+		return &RateLimitError{Provider: ep.name, Reset: reset}
+	}
+
+	//Apply per-provider timeout
+	if ep.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ep.timeout)
+		defer cancel()
+	}
+
+	//Add parameters to the URL
+	u, err := url.Parse(ep.url)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = append(query[key], values...)
+	}
+	if p.opts.ApiKey != "" {
+		query.Add("apikey", p.opts.ApiKey)
+	}
+	u.RawQuery = query.Encode()
+
+	//Create a new request
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	//Make the request
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return &ProviderError{Provider: ep.name, Err: err}
+	}
+	defer resp.Body.Close()
+
+	//Check rate limit headers and status code
+	if err := ep.checkResponse(resp); err != nil {
+		p.logger.Warnw("Provider returned an error", "provider", ep.name, "status", resp.StatusCode, "error", err)
+		return err
+	}
+
+	//Parse the response
+	if err := p.decode(ep, resp, v); err != nil {
+		p.logger.Warnw("Provider returned an invalid response", "provider", ep.name, "error", err)
+		return err
+	}
+	return nil
+}
+
+// decode reads at most MaxResponseSize bytes of the json response into v and validates the decoded values
+func (p *APIProvider) decode(ep *endpoint, resp *http.Response, v any) error {
+	//Only json is accepted, e.g. an HTML error page of a proxy is not
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return &DecodeError{Provider: ep.name, Reason: fmt.Sprintf("unexpected content type %q", resp.Header.Get("Content-Type"))}
+	}
+
+	//Read the body without letting the provider exhaust memory
+	limit := p.opts.MaxResponseSize
+	if resp.ContentLength > limit {
+		return &DecodeError{Provider: ep.name, Reason: fmt.Sprintf("response of %d bytes exceeds %d bytes", resp.ContentLength, limit)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return &ProviderError{Provider: ep.name, Err: err}
+	}
+	if int64(len(body)) > limit {
+		return &DecodeError{Provider: ep.name, Reason: fmt.Sprintf("response exceeds %d bytes", limit)}
+	}
+
+	//Decode a single json value
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(v); err != nil {
+		return &DecodeError{Provider: ep.name, Reason: "malformed json", Err: err}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return &DecodeError{Provider: ep.name, Reason: "unexpected data after json value"}
+	}
+
+	//Check the decoded values
+	if err := validateResponse(v); err != nil {
+		return &DecodeError{Provider: ep.name, Reason: err.Error()}
+	}
+	return nil
+}
+
+// exhausted reports whether the provider quota is known to be exhausted and when it resets
+func (ep *endpoint) exhausted() (time.Time, bool) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.exhaustedUntil, time.Now().Before(ep.exhaustedUntil)
+}
+
+// checkResponse remembers when the provider quota is exhausted and converts error status codes into errors
+func (ep *endpoint) checkResponse(resp *http.Response) error {
+	reset := resetTime(resp.Header)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.Header.Get("X-Rate-Limit-Remaining") == "0" {
+		ep.mu.Lock()
+		ep.exhaustedUntil = reset
+		ep.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{Provider: ep.name, Reset: reset}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{Provider: ep.name, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// resetTime returns the time when the provider quota resets
+// X-Rate-Limit-Reset and Retry-After contain number of seconds until the reset
+func resetTime(header http.Header) time.Time {
+	for _, key := range []string{"X-Rate-Limit-Reset", "Retry-After"} {
+		if seconds, err := strconv.Atoi(header.Get(key)); err == nil && seconds >= 0 {
+			return time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	return time.Time{}
+}
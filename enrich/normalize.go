@@ -0,0 +1,75 @@
+package enrich
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cyrillicToLatin transliterates Russian and Ukrainian letters following the common passport spelling
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// NormalizeName trims and collapses spaces, transliterates Cyrillic to Latin and title-cases every part of the name
+// "Иван", "ivan " and "IVAN" are all normalized to "Ivan"
+func NormalizeName(name string) string {
+	var b strings.Builder
+	wordStart := true
+	for _, r := range strings.Join(strings.Fields(name), " ") {
+		r = unicode.ToLower(r)
+		s, ok := cyrillicToLatin[r]
+		if !ok {
+			s = string(r)
+		}
+		if wordStart && s != "" {
+			first, size := utf8.DecodeRuneInString(s)
+			s = string(unicode.ToUpper(first)) + s[size:]
+			wordStart = false
+		}
+		b.WriteString(s)
+
+		//Each part of double names like Anna-Maria starts with a capital letter
+		if r == ' ' || r == '-' {
+			wordStart = true
+		}
+	}
+	return b.String()
+}
+
+// NormalizeCountryID trims and uppercases the country code so that "ru " and "RU" are the same country
+func NormalizeCountryID(countryID string) string {
+	return strings.ToUpper(strings.TrimSpace(countryID))
+}
+
+// normalizeInput normalizes all parts of the name and the country hint, the original spelling is kept
+func normalizeInput(in Input) Input {
+	if in.surnameOriginal == "" && in.patronymicOriginal == "" {
+		in.surnameOriginal, in.patronymicOriginal = in.Surname, in.Patronymic
+	}
+	return Input{
+		Name:       NormalizeName(in.Name),
+		Surname:    NormalizeName(in.Surname),
+		Patronymic: NormalizeName(in.Patronymic),
+		CountryID:  NormalizeCountryID(in.CountryID),
+
+		Age:         in.Age,
+		Gender:      in.Gender,
+		Nationality: in.Nationality,
+
+		surnameOriginal:    in.surnameOriginal,
+		patronymicOriginal: in.patronymicOriginal,
+	}
+}
+
+// normalizeInputs normalizes every input into a new slice
+func normalizeInputs(inputs []Input) []Input {
+	normalized := make([]Input, len(inputs))
+	for i, in := range inputs {
+		normalized[i] = normalizeInput(in)
+	}
+	return normalized
+}
@@ -0,0 +1,29 @@
+package enrich
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Ivan":       "Ivan",
+		"ivan ":      "Ivan",
+		"IVAN":       "Ivan",
+		"Иван":       "Ivan",
+		"  иван  ":   "Ivan",
+		"Щукина":     "Shchukina",
+		"Юрьевич":    "Yurevich",
+		"Олексій":    "Oleksiy",
+		"Їжакевич":   "Yizhakevich",
+		"анна-мария": "Anna-Mariya",
+		"Mary   Ann": "Mary Ann",
+		"élodie":     "Élodie",
+		"":           "",
+		"   ":        "",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, NormalizeName(in), in)
+	}
+}
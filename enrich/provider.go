@@ -0,0 +1,121 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dafraer/effective-mobile-task/errkind"
+)
+
+// ErrNoResult is returned by providers that have no answer for the input, the enricher then asks the next provider
+// It matches errkind.ErrUpstream like other provider errors
+var ErrNoResult = errkind.New(errkind.ErrUpstream, "provider has no result", nil)
+
+// AgeProvider guesses age of a person
+type AgeProvider interface {
+	Age(ctx context.Context, in Input) (*AgeResponse, error)
+}
+
+// GenderProvider guesses gender of a person
+type GenderProvider interface {
+	Gender(ctx context.Context, in Input) (*GenderResponse, error)
+}
+
+// NationalityProvider guesses countries a person may be from ranked by probability
+type NationalityProvider interface {
+	Nationality(ctx context.Context, in Input) (*NationalityResponse, error)
+}
+
+// AgeBatchProvider is implemented by age providers that can answer for many people at once
+// The returned slice has the same order as inputs with nil entries for inputs the provider has no answer for
+type AgeBatchProvider interface {
+	Ages(ctx context.Context, inputs []Input) ([]*AgeResponse, error)
+}
+
+// GenderBatchProvider is implemented by gender providers that can answer for many people at once
+// The returned slice has the same order as inputs with nil entries for inputs the provider has no answer for
+type GenderBatchProvider interface {
+	Genders(ctx context.Context, inputs []Input) ([]*GenderResponse, error)
+}
+
+// NationalityBatchProvider is implemented by nationality providers that can answer for many people at once
+// The returned slice has the same order as inputs with nil entries for inputs the provider has no answer for
+type NationalityBatchProvider interface {
+	Nationalities(ctx context.Context, inputs []Input) ([]*NationalityResponse, error)
+}
+
+// Named is implemented by providers that report their name in the provenance of the fields they resolve
+// field is age, gender or nationality
+type Named interface {
+	ProviderName(field string) string
+}
+
+// providerName returns the name of the provider of the field, providers that are not Named are named by their type
+func providerName(p any, field string) string {
+	if named, ok := p.(Named); ok {
+		return named.ProviderName(field)
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// Providers lists providers of each field in the order they are asked
+// The next provider is asked only if the previous one failed or had no result
+type Providers struct {
+	Age         []AgeProvider
+	Gender      []GenderProvider
+	Nationality []NationalityProvider
+}
+
+// Responses have the Source of the answer, the enricher sets it to the name of the provider unless the provider did
+
+// Uncounted is set by providers that do not report the number of samples, MinAgeSampleCount is not applied to their answers
+type AgeResponse struct {
+	Count     int    `json:"count"`
+	Name      string `json:"name"`
+	Age       int    `json:"age"`
+	Source    string `json:"-"`
+	Uncounted bool   `json:"-"`
+}
+
+type GenderResponse struct {
+	Count       int     `json:"count"`
+	Name        string  `json:"name"`
+	Gender      string  `json:"gender"`
+	Probability float64 `json:"probability"`
+	Source      string  `json:"-"`
+}
+
+type Country struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+type NationalityResponse struct {
+	Count   int       `json:"count"`
+	Name    string    `json:"name"`
+	Country []Country `json:"country"`
+	Source  string    `json:"-"`
+}
+
+// sourced is implemented by the responses so that the enricher can record which provider answered
+type sourced interface {
+	setSource(source string)
+}
+
+func (r *AgeResponse) setSource(source string) {
+	if r.Source == "" {
+		r.Source = source
+	}
+}
+
+func (r *GenderResponse) setSource(source string) {
+	if r.Source == "" {
+		r.Source = source
+	}
+}
+
+func (r *NationalityResponse) setSource(source string) {
+	if r.Source == "" {
+		r.Source = source
+	}
+}
@@ -0,0 +1,94 @@
+package enrich_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dafraer/effective-mobile-task/enrich"
+	"github.com/dafraer/effective-mobile-task/enrich/enrichtest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestRecordedAPIs enriches a person using the recorded responses of the public APIs
+// Run with ENRICH_RECORD=1 to record the responses again from the live APIs
+func TestRecordedAPIs(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	recorder := enrichtest.NewRecorder("testdata", enrichtest.ModeFromEnv(), nil)
+	provider := enrich.NewAPIProvider(recorder.Client(), logger.Sugar(), enrich.APIOptions{})
+	enricher := enrich.NewAPIEnricher(provider, logger.Sugar(), enrich.Options{})
+
+	person, err := enricher.EnrichPerson(context.Background(), enrich.Input{Name: "Ivan", Surname: "Ivanov"})
+	assert.NoError(t, err)
+	assert.NotNil(t, person.Age)
+	assert.Equal(t, "male", *person.Gender)
+	assert.NotNil(t, person.Nationality)
+	assert.Equal(t, "agify", person.AgeProvenance.Source)
+	assert.Equal(t, "genderize", person.GenderProvenance.Source)
+	assert.Equal(t, "nationalize", person.NationalityProvenance.Source)
+
+	//Requests that were not recorded fail in replay mode
+	if enrichtest.ModeFromEnv() == enrichtest.Replay {
+		_, err = provider.Age(context.Background(), enrich.Input{Name: "Unrecorded"})
+		assert.ErrorIs(t, err, enrichtest.ErrNoFixture)
+	}
+}
+
+func TestFakeProviderScenarios(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+
+	server := enrichtest.NewServer()
+	t.Cleanup(server.Close)
+	server.Set("Nobody", enrichtest.Empty)
+	server.Set("Limited", enrichtest.RateLimited)
+	server.Set("Broken", enrichtest.Malformed)
+	newEnricher := func(opts enrich.Options) enrich.Enricher {
+		provider := enrich.NewAPIProvider(&http.Client{Timeout: time.Second * 5}, logger.Sugar(), server.Options())
+		return enrich.NewAPIEnricher(provider, logger.Sugar(), opts)
+	}
+
+	//Success
+	person, err := newEnricher(enrich.Options{}).EnrichPerson(context.Background(), enrich.Input{Name: "Maria"})
+	assert.NoError(t, err)
+	assert.Equal(t, 25, *person.Age)
+	assert.Equal(t, "female", *person.Gender)
+	assert.Equal(t, "RU", *person.Nationality)
+
+	//Empty answers leave the fields unknown
+	person, err = newEnricher(enrich.Options{AllowPartial: true}).EnrichPerson(context.Background(), enrich.Input{Name: "Nobody"})
+	assert.NoError(t, err)
+	assert.Nil(t, person.Age)
+	assert.Nil(t, person.Gender)
+	assert.Nil(t, person.Nationality)
+	assert.Equal(t, enrich.StatusFailed, person.NationalityStatus)
+
+	//429 is reported as an exhausted quota
+	_, err = newEnricher(enrich.Options{}).EnrichPerson(context.Background(), enrich.Input{Name: "Limited"})
+	assert.ErrorIs(t, err, enrich.ErrRateLimited)
+
+	//Malformed json is reported as an invalid response
+	_, err = newEnricher(enrich.Options{}).EnrichPerson(context.Background(), enrich.Input{Name: "Broken"})
+	assert.ErrorIs(t, err, enrich.ErrInvalidResponse)
+
+	//Batched requests are answered for every name
+	requests := server.Requests(enrichtest.GenderPath)
+	people, err := newEnricher(enrich.Options{AllowPartial: true}).EnrichPeople(context.Background(),
+		[]enrich.Input{{Name: "Ivan"}, {Name: "Olga"}, {Name: "Nobody"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "male", *people[0].Gender)
+	assert.Equal(t, "female", *people[1].Gender)
+	assert.Nil(t, people[2].Gender)
+	assert.Equal(t, requests+1, server.Requests(enrichtest.GenderPath))
+}
@@ -0,0 +1,79 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// RetryPolicy describes how failed requests to providers are retried
+// MaxAttempts of 0 or 1 disables retries, Jitter is a fraction of the backoff that is randomly added or subtracted
+type RetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	Multiplier           float64
+	Jitter               float64
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns policy that retries network errors and 5xx responses up to 3 times
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 200,
+		MaxBackoff:     time.Second * 2,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// backoff returns the wait time before the attempt following the given one
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// retryable reports whether the request that failed with err should be retried
+func (p RetryPolicy) retryable(ctx context.Context, err error) bool {
+	//The caller is no longer waiting for the result
+	if ctx.Err() != nil {
+		return false
+	}
+
+	//Provider explicitly refused to serve the request
+	if errors.Is(err, ErrRateLimited) {
+		return false
+	}
+
+	//Retry only configured status codes
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.RetryableStatusCodes, statusErr.StatusCode)
+	}
+
+	//Network errors and timeouts of a single attempt
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
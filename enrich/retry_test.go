@@ -0,0 +1,91 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// flakyServer returns a server that responds with status failures times before responding with body
+func flakyServer(t *testing.T, failures int, status int, body string) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) <= int64(failures) {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newRetryProvider(url string, policy RetryPolicy) *APIProvider {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+	return NewAPIProvider(&http.Client{Timeout: defaultTimeOut}, logger.Sugar(), APIOptions{AgeApiUrl: url, Retry: policy})
+}
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = time.Millisecond * 5
+	return policy
+}
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	server, calls := flakyServer(t, 2, http.StatusServiceUnavailable, `{"count":1000,"name":"Ivan","age":42}`)
+	provider := newRetryProvider(server.URL, testRetryPolicy())
+
+	age, err := provider.Age(context.Background(), Input{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, age.Age)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, calls := flakyServer(t, 5, http.StatusServiceUnavailable, `{"count":1000,"name":"Ivan","age":42}`)
+	provider := newRetryProvider(server.URL, testRetryPolicy())
+
+	_, err := provider.Age(context.Background(), Input{Name: "Ivan"})
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestRetrySkipsNonRetryableStatus(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusBadRequest, `{"count":1000,"name":"Ivan","age":42}`)
+	provider := newRetryProvider(server.URL, testRetryPolicy())
+
+	_, err := provider.Age(context.Background(), Input{Name: "Ivan"})
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	server, calls := flakyServer(t, 5, http.StatusServiceUnavailable, `{"count":1000,"name":"Ivan","age":42}`)
+	policy := testRetryPolicy()
+	policy.InitialBackoff = time.Second
+	policy.MaxBackoff = time.Second
+	provider := newRetryProvider(server.URL, policy)
+
+	//Backoff does not fit before the deadline so the enricher gives up after the first attempt
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	start := time.Now()
+	_, err := provider.Age(ctx, Input{Name: "Ivan"})
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, calls.Load())
+	assert.Less(t, time.Since(start), time.Millisecond*500)
+}
@@ -0,0 +1,88 @@
+package enrich
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Probabilities reported by the Slavic rules
+// Patronymic suffixes are unambiguous, surname endings have rare exceptions
+const (
+	patronymicGenderProbability = 1
+	surnameGenderProbability    = 0.95
+)
+
+// genderSuffix maps an ending of a word to the gender it implies
+type genderSuffix struct {
+	suffix string
+	gender string
+}
+
+// patronymicSuffixes are endings of Russian and Ukrainian patronymics in Cyrillic and common Latin transliterations
+var patronymicSuffixes = []genderSuffix{
+	//Ivanovich, Ivanovych, Ivanovitch, Ilyich
+	{"ич", "male"}, {"ich", "male"}, {"ych", "male"}, {"itch", "male"},
+	//Ivanovna, Ivanivna, Ilyinichna
+	{"вна", "female"}, {"vna", "female"}, {"ична", "female"}, {"ichna", "female"},
+}
+
+// surnameSuffixes are endings of Russian, Ukrainian and Polish surnames that differ by gender
+var surnameSuffixes = []genderSuffix{
+	//Cyrillic: Ivanova, Sergeeva, Alyoshina, Dostoevskaya, Ivanov, Sergeev, Alyoshin, Dostoevsky
+	{"ова", "female"}, {"ева", "female"}, {"ёва", "female"}, {"ина", "female"}, {"ская", "female"}, {"цкая", "female"},
+	{"ов", "male"}, {"ев", "male"}, {"ёв", "male"}, {"ин", "male"}, {"ский", "male"}, {"цкий", "male"}, {"ской", "male"},
+	//Latin: -in and -ina are left out because they are common in other languages
+	{"skaya", "female"}, {"skaia", "female"}, {"ova", "female"}, {"eva", "female"}, {"ska", "female"},
+	{"skiy", "male"}, {"skii", "male"}, {"skij", "male"}, {"sky", "male"}, {"ski", "male"}, {"ov", "male"}, {"ev", "male"},
+}
+
+// SlavicGenderProvider infers gender of Russian and Ukrainian names from the patronymic and surname without network requests
+type SlavicGenderProvider struct {
+	logger *zap.SugaredLogger
+}
+
+// NewSlavicGenderProvider returns the rule-based gender provider
+func NewSlavicGenderProvider(logger *zap.SugaredLogger) *SlavicGenderProvider {
+	return &SlavicGenderProvider{logger: logger}
+}
+
+// ProviderName returns slavic for the gender
+func (p *SlavicGenderProvider) ProviderName(field string) string {
+	return "slavic"
+}
+
+// Gender returns gender implied by the patronymic or, if there is none, by the surname
+// The rules are applied to the spelling from before normalization, so Cyrillic endings match names that were transliterated
+// It returns ErrNoResult if neither of them has a known ending
+func (p *SlavicGenderProvider) Gender(ctx context.Context, in Input) (*GenderResponse, error) {
+	surname, patronymic := in.Surname, in.Patronymic
+	if in.surnameOriginal != "" || in.patronymicOriginal != "" {
+		surname, patronymic = in.surnameOriginal, in.patronymicOriginal
+	}
+	if gender, ok := matchSuffix(patronymic, patronymicSuffixes); ok {
+		p.logger.Debugw("Gender inferred from patronymic", "patronymic", patronymic, "gender", gender)
+		return &GenderResponse{Name: in.Name, Gender: gender, Probability: patronymicGenderProbability}, nil
+	}
+	if gender, ok := matchSuffix(surname, surnameSuffixes); ok {
+		p.logger.Debugw("Gender inferred from surname", "surname", surname, "gender", gender)
+		return &GenderResponse{Name: in.Name, Gender: gender, Probability: surnameGenderProbability}, nil
+	}
+	return nil, ErrNoResult
+}
+
+// matchSuffix returns gender of the first suffix the word ends with
+func matchSuffix(word string, suffixes []genderSuffix) (string, bool) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return "", false
+	}
+	for _, s := range suffixes {
+		//The suffix alone is not a word
+		if strings.HasSuffix(word, s.suffix) && len(word) > len(s.suffix) {
+			return s.gender, true
+		}
+	}
+	return "", false
+}
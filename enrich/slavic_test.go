@@ -0,0 +1,69 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSlavicGenderProvider(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+	provider := NewSlavicGenderProvider(logger.Sugar())
+
+	tests := []struct {
+		in          Input
+		gender      string
+		probability float64
+	}{
+		{Input{Name: "Ivan", Surname: "Ivanov", Patronymic: "Ivanovich"}, "male", 1},
+		{Input{Name: "Mykola", Surname: "Shevchenko", Patronymic: "Petrovych"}, "male", 1},
+		{Input{Name: "Vladimir", Surname: "Ulyanov", Patronymic: "Ilyich"}, "male", 1},
+		{Input{Name: "Anna", Surname: "Ivanova", Patronymic: "Sergeevna"}, "female", 1},
+		{Input{Name: "Oksana", Surname: "Shevchenko", Patronymic: "Ivanivna"}, "female", 1},
+		{Input{Name: "Иван", Surname: "Шевченко", Patronymic: "Петрович"}, "male", 1},
+		{Input{Name: "Анна", Surname: "Шевченко", Patronymic: "ИВАНОВНА"}, "female", 1},
+		{Input{Name: "Anna", Surname: "Dostoevskaya"}, "female", 0.95},
+		{Input{Name: "Fyodor", Surname: "Dostoevsky"}, "male", 0.95},
+		{Input{Name: "Пётр", Surname: "Алёшин"}, "male", 0.95},
+		{Input{Name: "Мария", Surname: "Сергеева"}, "female", 0.95},
+	}
+	for _, test := range tests {
+		gender, err := provider.Gender(context.Background(), test.in)
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.gender, gender.Gender, test.in)
+		assert.Equal(t, test.probability, gender.Probability, test.in)
+	}
+
+	//Gender neutral surnames without patronymic are left to other providers
+	_, err = provider.Gender(context.Background(), Input{Name: "Oksana", Surname: "Shevchenko"})
+	assert.ErrorIs(t, err, ErrNoResult)
+	_, err = provider.Gender(context.Background(), Input{Name: "Anna", Surname: "Medina"})
+	assert.ErrorIs(t, err, ErrNoResult)
+}
+
+func TestSlavicGenderEnricher(t *testing.T) {
+	//Create logger
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Errorf("error while creating new Logger, %v ", err))
+	}
+	enricher := New(logger.Sugar(), Providers{Gender: []GenderProvider{NewSlavicGenderProvider(logger.Sugar())}}, Options{AllowPartial: true})
+
+	//Cyrillic endings match although the names are transliterated before the lookups
+	person, err := enricher.EnrichPerson(context.Background(), Input{Name: "Пётр", Surname: "Алёшин"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Aleshin", person.Surname)
+	assert.Equal(t, ptr("male"), person.Gender)
+	people, err := enricher.EnrichPeople(context.Background(), []Input{{Name: "Ольга", Surname: "Ильина"}, {Name: "Anna", Surname: "Medina"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ilina", people[0].Surname)
+	assert.Equal(t, ptr("female"), people[0].Gender)
+	assert.Equal(t, StatusFailed, people[1].GenderStatus)
+}
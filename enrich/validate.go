@@ -0,0 +1,103 @@
+package enrich
+
+import (
+	"fmt"
+)
+
+// maxAge is the largest age a provider can answer with, larger values mean a broken response
+const maxAge = 150
+
+// validator is implemented by provider responses that can check their values
+type validator interface {
+	validate() error
+}
+
+// validateResponse checks a decoded single or batched provider response, other values are not checked
+func validateResponse(v any) error {
+	switch v := v.(type) {
+	case validator:
+		return v.validate()
+	case *[]AgeResponse:
+		return validateAll(*v)
+	case *[]GenderResponse:
+		return validateAll(*v)
+	case *[]NationalityResponse:
+		return validateAll(*v)
+	}
+	return nil
+}
+
+// validateAll checks every response of a batch
+func validateAll[R any, PR interface {
+	*R
+	validator
+}](responses []R) error {
+	for i := range responses {
+		if err := PR(&responses[i]).validate(); err != nil {
+			return fmt.Errorf("result %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *AgeResponse) validate() error {
+	if r.Count < 0 {
+		return fmt.Errorf("negative count %d", r.Count)
+	}
+	if r.Age < 0 || r.Age > maxAge {
+		return fmt.Errorf("age %d is out of range", r.Age)
+	}
+	return nil
+}
+
+func (r *GenderResponse) validate() error {
+	if r.Count < 0 {
+		return fmt.Errorf("negative count %d", r.Count)
+	}
+	if r.Gender != "" && r.Gender != "male" && r.Gender != "female" {
+		return fmt.Errorf("unknown gender %q", r.Gender)
+	}
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("gender probability %v is out of range", r.Probability)
+	}
+	return nil
+}
+
+func (r *NationalityResponse) validate() error {
+	if r.Count < 0 {
+		return fmt.Errorf("negative count %d", r.Count)
+	}
+	for _, c := range r.Country {
+		if !IsCountryID(c.CountryID) {
+			return fmt.Errorf("invalid country code %q", c.CountryID)
+		}
+		if c.Probability < 0 || c.Probability > 1 {
+			return fmt.Errorf("probability %v of %s is out of range", c.Probability, c.CountryID)
+		}
+	}
+	return nil
+}
+
+// validateInput checks the normalized input, the name is required and the country hint has to be a country code
+func validateInput(in Input) error {
+	if in.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if in.CountryID != "" && !IsCountryID(in.CountryID) {
+		return fmt.Errorf("%w: country hint %q is not a two-letter country code", ErrInvalidInput, in.CountryID)
+	}
+	return nil
+}
+
+// IsCountryID reports whether s is an ISO 3166-1 alpha-2 code normalized with NormalizeCountryID
+func IsCountryID(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
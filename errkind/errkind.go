@@ -0,0 +1,37 @@
+// Package errkind defines the kinds of errors shared by the store and the enricher
+// Errors of both packages match one of the kinds with errors.Is, so that callers like the api can handle them
+// without knowing which package they came from
+package errkind
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("not found")         //the requested record does not exist
+	ErrConflict   = errors.New("conflict")          //the change conflicts with the current state of the data
+	ErrValidation = errors.New("validation failed") //the input is invalid
+	ErrUpstream   = errors.New("upstream failure")  //an external service failed or responded with an error
+)
+
+// Error is an error of a kind with a message that can be shown to the client, Err is the underlying cause
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+// New returns an error of the kind with the message
+func New(kind error, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,24 @@
+package errkind
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("pq: value out of range")
+	err := fmt.Errorf("error saving person: %w", New(ErrValidation, "age is out of range", cause))
+
+	//Error matches its kind and the cause, but no other kind
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	//Message is shown instead of the cause
+	var kindErr *Error
+	assert.ErrorAs(t, err, &kindErr)
+	assert.Equal(t, "age is out of range", kindErr.Error())
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// purgeInterval is how often expired entries are deleted when values are saved
+const purgeInterval = time.Hour
+
+// EnrichmentCache stores enrichment results in the enrichment_cache table
+// Expired entries are not returned and are deleted from time to time when new values are saved
+type EnrichmentCache struct {
+	db        *sql.DB
+	logger    *zap.SugaredLogger
+	lastPurge atomic.Int64
+}
+
+func NewEnrichmentCache(db *sql.DB, logger *zap.SugaredLogger) *EnrichmentCache {
+	return &EnrichmentCache{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetCached returns unexpired cached value by key
+func (c *EnrichmentCache) GetCached(ctx context.Context, key string) ([]byte, bool, error) {
+	c.logger.Debugw("GetCached called", "key", key)
+
+	var value []byte
+	err := c.db.QueryRowContext(ctx, "SELECT value FROM enrichment_cache WHERE key = $1 AND expires_at > NOW();", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// SetCached saves value by key, replacing the existing one, and purges expired entries if they were not purged for purgeInterval
+func (c *EnrichmentCache) SetCached(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.logger.Debugw("SetCached called", "key", key, "ttl", ttl)
+
+	_, err := c.db.ExecContext(ctx, `
+	INSERT INTO enrichment_cache (key, value, expires_at) VALUES ($1, $2, $3)
+	ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at;
+	`, key, value, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	//Only one of the concurrent writers purges
+	last := c.lastPurge.Load()
+	if time.Since(time.Unix(0, last)) >= purgeInterval && c.lastPurge.CompareAndSwap(last, time.Now().UnixNano()) {
+		c.purgeExpired(ctx)
+	}
+	return nil
+}
+
+// purgeExpired deletes expired entries so that the table does not grow without bound, errors are logged and ignored
+func (c *EnrichmentCache) purgeExpired(ctx context.Context) {
+	result, err := c.db.ExecContext(ctx, "DELETE FROM enrichment_cache WHERE expires_at <= NOW();")
+	if err != nil {
+		c.logger.Errorw("Error purging expired enrichment cache entries", "error", err)
+		return
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		c.logger.Infow("Expired enrichment cache entries purged", "count", rows)
+	}
+}
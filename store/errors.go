@@ -0,0 +1,44 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/dafraer/effective-mobile-task/errkind"
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when the requested person does not exist, it matches errkind.ErrNotFound
+var ErrNotFound = errkind.New(errkind.ErrNotFound, "person not found", nil)
+
+// affected returns ErrNotFound if the statement did not change any row, other errors are classified
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return classify(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// classify converts database errors caused by the data into errors of the matching kind
+// Integrity violations and concurrent modifications are conflicts, data exceptions like out of range values are validation errors
+// Other errors are returned as they are
+func classify(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch {
+	case pqErr.Code == "23505" || pqErr.Code == "23P01" || pqErr.Code.Class() == "40":
+		return errkind.New(errkind.ErrConflict, pqErr.Message, err)
+	case pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23":
+		return errkind.New(errkind.ErrValidation, pqErr.Message, err)
+	}
+	return err
+}
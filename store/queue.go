@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Enrichment statuses of a person
+const (
+	EnrichmentPending = "pending" //waiting for a worker
+	EnrichmentDone    = "done"    //enriched
+	EnrichmentDead    = "dead"    //gave up after too many failed attempts
+)
+
+// FieldPending is the status of the fields of a pending person that are waiting for a worker
+// The fields that are still pending when the person is dead-lettered are marked as failed
+const FieldPending = "pending"
+
+// ClaimPending returns up to limit pending people that are due for enrichment and increments their attempts
+// Returned people have the names, the country hint and the fields provided by the client with their provenance, enriched fields are not loaded
+// Claimed people are hidden from other workers for lease, so they are claimed again if the worker dies
+// Rows locked by concurrent workers are skipped
+func (s *Store) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Person, error) {
+	s.logger.Debugw("ClaimPending called", "limit", limit, "lease", lease)
+
+	rows, err := s.db.QueryContext(ctx, `
+	UPDATE people
+	SET enrichment_attempts = enrichment_attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+	WHERE id IN (
+		SELECT id FROM people
+		WHERE enrichment_status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY id LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, name, surname, patronymic, age, gender, nationality, country_hint, name_original, surname_original, patronymic_original,
+	age_status, gender_status, nationality_status, enrichment_status, enrichment_attempts, enrichment_error,
+	age_provenance, gender_provenance, nationality_provenance;
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var people []*Person
+	for rows.Next() {
+		var p Person
+		var provenance [3][]byte
+		if err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality, &p.CountryHint,
+			&p.NameOriginal, &p.SurnameOriginal, &p.PatronymicOriginal, &p.AgeStatus, &p.GenderStatus, &p.NationalityStatus,
+			&p.EnrichmentStatus, &p.EnrichmentAttempts, &p.EnrichmentError, &provenance[0], &provenance[1], &provenance[2]); err != nil {
+			return nil, err
+		}
+		if err := unmarshalProvenance(provenance, &p); err != nil {
+			return nil, err
+		}
+		people = append(people, &p)
+	}
+	return people, rows.Err()
+}
+
+// CompleteEnrichment saves the enriched fields of the person and marks them as done
+// It is used both by the asynchronous workers and to save re-enriched people
+// ErrNotFound is returned if the person was deleted in the meantime
+func (s *Store) CompleteEnrichment(ctx context.Context, person *Person) error {
+	s.logger.Debugw("CompleteEnrichment called", "person", *person)
+
+	candidates, err := marshalCandidates(person.NationalityCandidates)
+	if err != nil {
+		return err
+	}
+	provenance, err := marshalProvenance(person)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `
+	UPDATE people
+	SET
+	age = $2,
+	gender = $3,
+	nationality = $4,
+	country_hint = $5,
+	age_sample_count = $6,
+	gender_probability = $7,
+	nationality_probability = $8,
+	nationality_candidates = $9,
+	age_status = $10,
+	gender_status = $11,
+	nationality_status = $12,
+	enrichment_status = 'done',
+	enrichment_error = '',
+	enriched_at = $13,
+	age_provenance = $14,
+	gender_provenance = $15,
+	nationality_provenance = $16
+	WHERE id = $1;
+	`, person.ID, person.Age, person.Gender, person.Nationality, person.CountryHint,
+		person.AgeSampleCount, person.GenderProbability, person.NationalityProbability, candidates,
+		person.AgeStatus, person.GenderStatus, person.NationalityStatus, person.EnrichedAt, provenance[0], provenance[1], provenance[2])
+	return affected(result, err)
+}
+
+// FailEnrichment records the failed attempt, the person is retried at retryAt or dead-lettered if retryAt is nil
+// Pending fields of a dead-lettered person are marked as failed, ErrNotFound is returned if the person was deleted in the meantime
+func (s *Store) FailEnrichment(ctx context.Context, id int, message string, retryAt *time.Time) error {
+	s.logger.Debugw("FailEnrichment called", "id", id, "message", message, "retry_at", retryAt)
+
+	status := EnrichmentPending
+	if retryAt == nil {
+		status = EnrichmentDead
+	}
+	result, err := s.db.ExecContext(ctx, `
+	UPDATE people
+	SET enrichment_status = $2, enrichment_error = $3, next_attempt_at = COALESCE($4::TIMESTAMPTZ, next_attempt_at),
+	age_status = CASE WHEN $2 = 'dead' AND age_status = 'pending' THEN 'failed' ELSE age_status END,
+	gender_status = CASE WHEN $2 = 'dead' AND gender_status = 'pending' THEN 'failed' ELSE gender_status END,
+	nationality_status = CASE WHEN $2 = 'dead' AND nationality_status = 'pending' THEN 'failed' ELSE nationality_status END
+	WHERE id = $1;
+	`, id, status, message, retryAt)
+	return affected(result, err)
+}
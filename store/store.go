@@ -3,9 +3,12 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -14,6 +17,10 @@ type Storer interface {
 	SavePerson(ctx context.Context, person *Person) (int, error)
 	UpdatePerson(ctx context.Context, person *Person) error
 	GetPeople(ctx context.Context, params *GetParams) ([]*Person, error)
+	GetPerson(ctx context.Context, id int) (*Person, error)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Person, error)
+	CompleteEnrichment(ctx context.Context, person *Person) error
+	FailEnrichment(ctx context.Context, id int, message string, retryAt *time.Time) error
 }
 
 type Store struct {
@@ -21,14 +28,71 @@ type Store struct {
 	logger *zap.SugaredLogger
 }
 
+// Person is a person stored in the database
+// Age, gender and nationality are nil if they are unknown
 type Person struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Surname     string  `json:"surname"`
+	Patronymic  string  `json:"patronymic"`
+	Age         *int    `json:"age"`
+	Gender      *string `json:"gender"`
+	Nationality *string `json:"nationality"`
+
+	//Name as it was submitted before normalization
+	NameOriginal       string `json:"name_original"`
+	SurnameOriginal    string `json:"surname_original"`
+	PatronymicOriginal string `json:"patronymic_original"`
+
+	//Country that age and gender were localized to, empty if they were not
+	CountryHint string `json:"country_hint"`
+
+	//Confidence of the enriched values
+	AgeSampleCount         int     `json:"age_sample_count"`
+	GenderProbability      float64 `json:"gender_probability"`
+	NationalityProbability float64 `json:"nationality_probability"`
+
+	//Countries the person may be from ranked by probability
+	NationalityCandidates []NationalityCandidate `json:"nationality_candidates"`
+
+	//Enrichment status of each field: ok, failed, skipped, provided or pending until a worker enriches it
+	AgeStatus         string `json:"age_status"`
+	GenderStatus      string `json:"gender_status"`
+	NationalityStatus string `json:"nationality_status"`
+
+	//Asynchronous enrichment state: pending, done or dead, with the number of attempts and the last error
+	EnrichmentStatus   string `json:"enrichment_status"`
+	EnrichmentAttempts int    `json:"enrichment_attempts"`
+	EnrichmentError    string `json:"enrichment_error"`
+
+	//When the person was last enriched, nil if they have never been
+	EnrichedAt *time.Time `json:"enriched_at"`
+
+	//Where the value of each field came from, nil if it is unknown
+	AgeProvenance         *Provenance `json:"age_provenance"`
+	GenderProvenance      *Provenance `json:"gender_provenance"`
+	NationalityProvenance *Provenance `json:"nationality_provenance"`
+}
+
+// Sources of the values that were not looked up by a provider
+const (
+	SourceClient = "client" //provided with /add
+	SourceUpdate = "update" //edited with /update
+)
+
+// Provenance tells where the value of a field came from: the provider that answered, the client or an edit
+// EditedBy is the user who provided or edited the value if it is known
+type Provenance struct {
+	Source      string    `json:"source"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Probability float64   `json:"probability,omitempty"`
+	SampleCount int       `json:"sample_count,omitempty"`
+	EditedBy    string    `json:"edited_by,omitempty"`
+}
+
+type NationalityCandidate struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
 }
 
 func New(db *sql.DB, logger *zap.SugaredLogger) Storer {
@@ -39,32 +103,58 @@ func New(db *sql.DB, logger *zap.SugaredLogger) Storer {
 }
 
 // Delete deletes person form the database by their ID
+// ErrNotFound is returned if there is no such person
 func (s *Store) DeletePerson(ctx context.Context, id int) error {
 	s.logger.Debugw("DeletePerson called", "id", id)
 
-	_, err := s.db.ExecContext(ctx, "DELETE FROM people WHERE id = $1;", id)
-	if err != nil {
-		return err
-	}
-	return nil
+	result, err := s.db.ExecContext(ctx, "DELETE FROM people WHERE id = $1;", id)
+	return affected(result, err)
 }
 
 // SavePerson saves a person to the database and returns the ID of the saved person
 func (s *Store) SavePerson(ctx context.Context, person *Person) (int, error) {
 	s.logger.Debugw("SavePerson called", "person", *person)
 
+	candidates, err := marshalCandidates(person.NationalityCandidates)
+	if err != nil {
+		return 0, err
+	}
+
+	provenance, err := marshalProvenance(person)
+	if err != nil {
+		return 0, err
+	}
+
 	var id int
-	err := s.db.QueryRowContext(ctx, "INSERT INTO people (name, surname, patronymic, age, gender, nationality) VALUES ($1, $2, $3, $4, $5, $6) RETURNING ID;",
-		person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality).Scan(&id)
+	err = s.db.QueryRowContext(ctx, `
+	INSERT INTO people (name, surname, patronymic, age, gender, nationality, age_sample_count, gender_probability, nationality_probability, nationality_candidates, country_hint,
+	name_original, surname_original, patronymic_original, age_status, gender_status, nationality_status, enrichment_status, enriched_at,
+	age_provenance, gender_provenance, nationality_provenance)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22) RETURNING ID;`,
+		person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality,
+		person.AgeSampleCount, person.GenderProbability, person.NationalityProbability, candidates, person.CountryHint,
+		person.NameOriginal, person.SurnameOriginal, person.PatronymicOriginal, fieldStatus(person.AgeStatus, person.EnrichmentStatus),
+		fieldStatus(person.GenderStatus, person.EnrichmentStatus), fieldStatus(person.NationalityStatus, person.EnrichmentStatus),
+		enrichmentStatus(person.EnrichmentStatus), person.EnrichedAt, provenance[0], provenance[1], provenance[2]).Scan(&id)
+	if err != nil {
+		return 0, classify(err)
+	}
 	s.logger.Debugw("Saved person", "id", id)
-	return id, err
+	return id, nil
 }
 
 // UpdatePerson updates a person in the database
+// Provenance and status of age, gender and nationality are replaced with the ones of the person only if the value has changed,
+// empty status keeps the old one
+// ErrNotFound is returned if there is no person with the ID
 func (s *Store) UpdatePerson(ctx context.Context, person *Person) error {
 	s.logger.Debugw("UpdatePerson called", "person", *person)
 
-	_, err := s.db.ExecContext(ctx, `
+	provenance, err := marshalProvenance(person)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `
 	UPDATE people 
 	SET 
 	name = $1 ,
@@ -72,10 +162,21 @@ func (s *Store) UpdatePerson(ctx context.Context, person *Person) error {
 	patronymic = $3,
 	age = $4,
 	gender = $5,
-	nationality = $6
+	nationality = $6,
+	name_original = $8,
+	surname_original = $9,
+	patronymic_original = $10,
+	age_provenance = CASE WHEN age IS DISTINCT FROM $4 THEN $11::JSONB ELSE age_provenance END,
+	gender_provenance = CASE WHEN gender IS DISTINCT FROM $5 THEN $12::JSONB ELSE gender_provenance END,
+	nationality_provenance = CASE WHEN nationality IS DISTINCT FROM $6 THEN $13::JSONB ELSE nationality_provenance END,
+	age_status = CASE WHEN age IS DISTINCT FROM $4 AND $14 <> '' THEN $14 ELSE age_status END,
+	gender_status = CASE WHEN gender IS DISTINCT FROM $5 AND $15 <> '' THEN $15 ELSE gender_status END,
+	nationality_status = CASE WHEN nationality IS DISTINCT FROM $6 AND $16 <> '' THEN $16 ELSE nationality_status END
 	WHERE id = $7;
-	 `, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality, person.ID)
-	return err
+	 `, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality, person.ID,
+		person.NameOriginal, person.SurnameOriginal, person.PatronymicOriginal, provenance[0], provenance[1], provenance[2],
+		person.AgeStatus, person.GenderStatus, person.NationalityStatus)
+	return affected(result, err)
 }
 
 type GetParams struct {
@@ -87,6 +188,19 @@ type GetParams struct {
 	Age         *int
 	Gender      *string
 	Nationality *string
+
+	//Filter by nationality candidate, optionally limited to the top ranks and minimal probability
+	Candidate            *string
+	CandidateTop         *int
+	CandidateProbability *float64
+
+	//Filter by asynchronous enrichment status
+	EnrichmentStatus *string
+
+	//Filters used to select people for re-enrichment
+	IDs            []int      //only people with these IDs
+	EnrichedBefore *time.Time //people enriched before this time or never enriched, pending people are skipped
+	Failed         *bool      //people with or without a failed field or dead-lettered enrichment
 }
 
 // NewParams populates GetParams struct and returns a pointer to it
@@ -122,10 +236,12 @@ func (s *Store) GetPeople(ctx context.Context, params *GetParams) ([]*Person, er
 
 	//Build query
 	q := strings.Builder{}
-	paramList := []interface{}{params.Limit, params.Name, params.Surname, params.Patronymic, params.Age, params.Gender, params.Nationality}
-	q.WriteString("SELECT id, name, surname, patronymic, age, gender, nationality FROM people WHERE ")
+	paramList := []interface{}{params.Limit, params.Name, params.Surname, params.Patronymic, params.Age, params.Gender, params.Nationality,
+		params.Candidate, params.CandidateTop, params.CandidateProbability, params.EnrichmentStatus,
+		pq.Array(params.IDs), params.EnrichedBefore, params.Failed}
+	q.WriteString(`SELECT ` + personColumns + ` FROM people WHERE `)
 	if params.Cursor != nil {
-		q.WriteString("id > $8 AND")
+		q.WriteString("id > $15 AND")
 		paramList = append(paramList, params.Cursor)
 	}
 	q.WriteString(`	($2::TEXT IS NULL OR name = $2) AND
@@ -133,7 +249,17 @@ func (s *Store) GetPeople(ctx context.Context, params *GetParams) ([]*Person, er
 	($4::TEXT IS NULL OR patronymic = $4) AND
 	($5::INTEGER IS NULL OR age = $5) AND
 	($6::TEXT IS NULL OR gender = $6) AND
-	($7::TEXT IS NULL OR nationality = $7)
+	($7::TEXT IS NULL OR nationality = $7) AND
+	($8::TEXT IS NULL OR EXISTS (
+		SELECT 1 FROM jsonb_array_elements(nationality_candidates) WITH ORDINALITY AS c(candidate, rank)
+		WHERE c.candidate->>'country_id' = $8 AND
+		($9::INTEGER IS NULL OR c.rank <= $9) AND
+		($10::DOUBLE PRECISION IS NULL OR (c.candidate->>'probability')::DOUBLE PRECISION > $10)
+	)) AND
+	($11::TEXT IS NULL OR enrichment_status = $11) AND
+	($12::INTEGER[] IS NULL OR id = ANY($12)) AND
+	($13::TIMESTAMPTZ IS NULL OR (enrichment_status <> 'pending' AND (enriched_at IS NULL OR enriched_at < $13))) AND
+	($14::BOOLEAN IS NULL OR $14 = (age_status = 'failed' OR gender_status = 'failed' OR nationality_status = 'failed' OR enrichment_status = 'dead'))
 	ORDER BY id LIMIT $1;
 	`)
 	//Get people from the database
@@ -146,13 +272,124 @@ func (s *Store) GetPeople(ctx context.Context, params *GetParams) ([]*Person, er
 	//Create a slice of people and scan rows
 	people := make([]*Person, 0, params.Limit)
 	for rows.Next() {
-		var p Person
-		if err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality); err != nil {
+		p, err := scanPerson(rows)
+		if err != nil {
 			return nil, err
 		}
-		people = append(people, &p)
+		people = append(people, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	s.logger.Debugw("Received people from the database", "people", people)
 
 	return people, nil
 }
+
+// GetPerson retrieves a person by id, ErrNotFound is returned if there is no such person
+func (s *Store) GetPerson(ctx context.Context, id int) (*Person, error) {
+	s.logger.Debugw("GetPerson called", "id", id)
+
+	p, err := scanPerson(s.db.QueryRowContext(ctx, `SELECT `+personColumns+` FROM people WHERE id = $1;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// personColumns are the columns of a person in the order scanPerson reads them
+const personColumns = `id, name, surname, patronymic, age, gender, nationality,
+	age_sample_count, gender_probability, nationality_probability, nationality_candidates, country_hint,
+	name_original, surname_original, patronymic_original, age_status, gender_status, nationality_status,
+	enrichment_status, enrichment_attempts, enrichment_error, enriched_at, age_provenance, gender_provenance, nationality_provenance`
+
+// scanPerson reads a person selected with personColumns from row, which is either *sql.Row or *sql.Rows
+func scanPerson(row interface{ Scan(dest ...any) error }) (*Person, error) {
+	var p Person
+	var candidates []byte
+	var provenance [3][]byte
+	if err := row.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality,
+		&p.AgeSampleCount, &p.GenderProbability, &p.NationalityProbability, &candidates, &p.CountryHint,
+		&p.NameOriginal, &p.SurnameOriginal, &p.PatronymicOriginal, &p.AgeStatus, &p.GenderStatus, &p.NationalityStatus,
+		&p.EnrichmentStatus, &p.EnrichmentAttempts, &p.EnrichmentError, &p.EnrichedAt, &provenance[0], &provenance[1], &provenance[2]); err != nil {
+		return nil, err
+	}
+	if err := unmarshalCandidates(candidates, &p); err != nil {
+		return nil, err
+	}
+	if err := unmarshalProvenance(provenance, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// enrichmentStatus defaults empty status to done, people are saved pending only by the asynchronous mode
+func enrichmentStatus(status string) string {
+	if status == "" {
+		return EnrichmentDone
+	}
+	return status
+}
+
+// fieldStatus defaults empty status of a field of a pending person to pending, fields provided by the client keep their status
+func fieldStatus(status, enrichment string) string {
+	if status == "" && enrichment == EnrichmentPending {
+		return FieldPending
+	}
+	return status
+}
+
+// marshalCandidates encodes nationality candidates as a json array, nil slice is encoded as an empty array
+func marshalCandidates(candidates []NationalityCandidate) ([]byte, error) {
+	if candidates == nil {
+		candidates = []NationalityCandidate{}
+	}
+	return json.Marshal(candidates)
+}
+
+// unmarshalCandidates decodes nationality candidates into the person leaving the slice nil if there are none
+func unmarshalCandidates(data []byte, p *Person) error {
+	var candidates []NationalityCandidate
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return err
+	}
+	if len(candidates) > 0 {
+		p.NationalityCandidates = candidates
+	}
+	return nil
+}
+
+// marshalProvenance encodes provenance of age, gender and nationality as json, nil provenance is encoded as NULL
+// Slots are left untyped nil for NULL, since the driver sends nil []byte as an empty value which is not valid json
+func marshalProvenance(person *Person) ([3]any, error) {
+	var encoded [3]any
+	for i, p := range []*Provenance{person.AgeProvenance, person.GenderProvenance, person.NationalityProvenance} {
+		if p == nil {
+			continue
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return encoded, err
+		}
+		encoded[i] = data
+	}
+	return encoded, nil
+}
+
+// unmarshalProvenance decodes provenance of age, gender and nationality into the person leaving NULL provenance nil
+func unmarshalProvenance(data [3][]byte, p *Person) error {
+	for i, target := range []**Provenance{&p.AgeProvenance, &p.GenderProvenance, &p.NationalityProvenance} {
+		if data[i] == nil {
+			continue
+		}
+		var provenance Provenance
+		if err := json.Unmarshal(data[i], &provenance); err != nil {
+			return err
+		}
+		*target = &provenance
+	}
+	return nil
+}
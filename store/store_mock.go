@@ -1,6 +1,9 @@
 package store
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type MockStore struct {
 }
@@ -9,7 +12,11 @@ func NewMockStore() Storer {
 	return &MockStore{}
 }
 
+// DeletePerson deletes only the person with id 1, ErrNotFound is returned for other ids
 func (*MockStore) DeletePerson(ctx context.Context, id int) error {
+	if id != 1 {
+		return ErrNotFound
+	}
 	return nil
 }
 
@@ -17,18 +24,70 @@ func (*MockStore) SavePerson(ctx context.Context, person *Person) (int, error) {
 	return 1, nil
 }
 
+// UpdatePerson updates only the person with id 1, ErrNotFound is returned for other ids
 func (*MockStore) UpdatePerson(ctx context.Context, person *Person) error {
+	if person.ID != 1 {
+		return ErrNotFound
+	}
 	return nil
 }
 
 func (*MockStore) GetPeople(ctx context.Context, params *GetParams) ([]*Person, error) {
+	age, gender, nationality := 30, "male", "russian"
 	return []*Person{{
 		ID:          1,
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         30,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         &age,
+		Gender:      &gender,
+		Nationality: &nationality,
+
+		NameOriginal:       "Иван",
+		SurnameOriginal:    "Иванов",
+		PatronymicOriginal: "Иванович",
+
+		AgeSampleCount:         1000,
+		GenderProbability:      0.99,
+		NationalityProbability: 0.4,
+		NationalityCandidates:  []NationalityCandidate{{CountryID: "RU", Probability: 0.4}, {CountryID: "UA", Probability: 0.2}},
+		AgeStatus:              "ok",
+		GenderStatus:           "ok",
+		NationalityStatus:      "provided",
+		EnrichmentStatus:       EnrichmentDone,
+
+		AgeProvenance:         &Provenance{Source: "agify", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), SampleCount: 1000},
+		GenderProvenance:      &Provenance{Source: "genderize", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Probability: 0.99},
+		NationalityProvenance: &Provenance{Source: SourceUpdate, FetchedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), EditedBy: "operator"},
 	}}, nil
 }
+
+// GetPerson returns the person of GetPeople for id 1 and ErrNotFound for other ids
+func (m *MockStore) GetPerson(ctx context.Context, id int) (*Person, error) {
+	if id != 1 {
+		return nil, ErrNotFound
+	}
+	people, err := m.GetPeople(ctx, &GetParams{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	return people[0], nil
+}
+
+func (*MockStore) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Person, error) {
+	return nil, nil
+}
+
+func (*MockStore) CompleteEnrichment(ctx context.Context, person *Person) error {
+	if person.ID != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (*MockStore) FailEnrichment(ctx context.Context, id int, message string, retryAt *time.Time) error {
+	if id != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
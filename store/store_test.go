@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/dafraer/effective-mobile-task/errkind"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -28,9 +30,17 @@ func TestSavePerson(t *testing.T) {
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         30,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         ptr(30),
+		Gender:      ptr("male"),
+		Nationality: ptr("russian"),
+
+		NameOriginal:       "Иван",
+		SurnameOriginal:    "Иванов",
+		PatronymicOriginal: "Иванович",
+
+		AgeSampleCount:         1000,
+		GenderProbability:      0.99,
+		NationalityProbability: 0.4,
 	}
 	id, err := store.SavePerson(context.Background(), &person)
 	assert.NoError(t, err)
@@ -50,9 +60,9 @@ func TestDeletePerson(t *testing.T) {
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         30,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         ptr(30),
+		Gender:      ptr("male"),
+		Nationality: ptr("russian"),
 	}
 	id, err := store.SavePerson(context.Background(), &person)
 	assert.NoError(t, err)
@@ -74,116 +84,125 @@ func TestGetPeople(t *testing.T) {
 			Name:        "Ivan",
 			Surname:     "Petrov",
 			Patronymic:  "Sergeevich",
-			Age:         35,
-			Gender:      "male",
-			Nationality: "russian",
+			Age:         ptr(35),
+			Gender:      ptr("male"),
+			Nationality: ptr("russian"),
+
+			NameOriginal:       "иван",
+			SurnameOriginal:    "ПЕТРОВ",
+			PatronymicOriginal: "Сергеевич",
+
+			AgeStatus:         "ok",
+			GenderStatus:      "ok",
+			NationalityStatus: "failed",
 		},
 		{
 			ID:          2,
 			Name:        "Maria",
 			Surname:     "Kuznetsova",
 			Patronymic:  "Andreevna",
-			Age:         28,
-			Gender:      "female",
-			Nationality: "ukrainian",
+			Age:         ptr(28),
+			Gender:      ptr("female"),
+			Nationality: ptr("ukrainian"),
 		},
 		{
 			ID:          3,
 			Name:        "Dmitry",
 			Surname:     "Smirnov",
 			Patronymic:  "Alexeevich",
-			Age:         42,
-			Gender:      "male",
-			Nationality: "russian",
+			Age:         ptr(42),
+			Gender:      ptr("male"),
+			Nationality: ptr("russian"),
 		},
 		{
 			ID:          4,
 			Name:        "Svetlana",
 			Surname:     "Popova",
 			Patronymic:  "Ivanovna",
-			Age:         22,
-			Gender:      "female",
-			Nationality: "belarusian",
+			Age:         ptr(22),
+			Gender:      ptr("female"),
+			Nationality: ptr("belarusian"),
 		},
 		{
 			ID:          5,
 			Name:        "Alexei",
 			Surname:     "Vasiliev",
 			Patronymic:  "Dmitrievich",
-			Age:         50,
-			Gender:      "male",
-			Nationality: "russian",
+			Age:         ptr(50),
+			Gender:      ptr("male"),
+			Nationality: ptr("russian"),
 		},
 		{
 			ID:          6,
 			Name:        "Elena",
 			Surname:     "Ivanova",
 			Patronymic:  "",
-			Age:         61,
-			Gender:      "female",
-			Nationality: "kazakh",
+			Age:         ptr(61),
+			Gender:      ptr("female"),
+			Nationality: ptr("kazakh"),
 		},
 		{
 			ID:          7,
 			Name:        "Sergei",
 			Surname:     "Mikhailov",
 			Patronymic:  "Nikolaevich",
-			Age:         29,
-			Gender:      "male",
-			Nationality: "russian",
+			Age:         ptr(29),
+			Gender:      ptr("male"),
+			Nationality: ptr("russian"),
 		},
 		{
 			ID:          8,
 			Name:        "Olga",
 			Surname:     "Fedorova",
 			Patronymic:  "Petrovna",
-			Age:         45,
-			Gender:      "female",
-			Nationality: "ukrainian",
+			Age:         ptr(45),
+			Gender:      ptr("female"),
+			Nationality: ptr("ukrainian"),
 		},
 		{
 			ID:          9,
 			Name:        "Nikolai",
 			Surname:     "Morozov",
 			Patronymic:  "Ivanovich",
-			Age:         61,
-			Gender:      "male",
-			Nationality: "belarusian",
+			Age:         ptr(61),
+			Gender:      ptr("male"),
+			Nationality: ptr("belarusian"),
 		},
 		{
 			ID:          10,
 			Name:        "Tatiana",
 			Surname:     "Ivanova",
 			Patronymic:  "Sergeevna",
-			Age:         25,
-			Gender:      "female",
-			Nationality: "russian",
+			Age:         ptr(25),
+			Gender:      ptr("female"),
+			Nationality: ptr("russian"),
 		},
 		{
 			ID:          11,
 			Name:        "Andrei",
 			Surname:     "Novikov",
 			Patronymic:  "Vladimirovich",
-			Age:         38,
-			Gender:      "male",
-			Nationality: "georgian",
+			Age:         ptr(38),
+			Gender:      ptr("male"),
+			Nationality: ptr("georgian"),
 		},
 		{
 			ID:          12,
 			Name:        "Anna",
 			Surname:     "Ivanova",
 			Patronymic:  "Alexeevna",
-			Age:         29,
-			Gender:      "female",
-			Nationality: "kazakh",
+			Age:         ptr(29),
+			Gender:      ptr("female"),
+			Nationality: ptr("kazakh"),
 		},
 	}
 
-	//Save people to the database
+	//Save people to the database, people saved without a status are marked as enriched
 	for _, p := range people {
 		id, err := store.SavePerson(context.Background(), p)
 		assert.NoError(t, err)
 		assert.Equal(t, id, p.ID)
+		p.EnrichmentStatus = EnrichmentDone
 	}
 
 	//Get everyone from the db
@@ -262,6 +281,59 @@ func TestGetPeople(t *testing.T) {
 	assert.EqualValues(t, peopleFromDB[0], people[10])
 }
 
+func TestGetPeopleByCandidate(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Set up people with different nationality candidates
+	people := []*Person{
+		{
+			ID:                     1,
+			Name:                   "Ivan",
+			Surname:                "Ivanov",
+			Nationality:            ptr("RU"),
+			NationalityProbability: 0.4,
+			NationalityCandidates:  []NationalityCandidate{{"RU", 0.4}, {"UA", 0.3}, {"BY", 0.1}},
+		},
+		{
+			ID:                     2,
+			Name:                   "Ivan",
+			Surname:                "Petrov",
+			Nationality:            ptr("RU"),
+			NationalityProbability: 0.5,
+			NationalityCandidates:  []NationalityCandidate{{"RU", 0.5}, {"BY", 0.2}, {"KZ", 0.1}, {"UA", 0.05}},
+		},
+		{
+			ID:                     3,
+			Name:                   "Taras",
+			Surname:                "Shevchenko",
+			Nationality:            ptr("UA"),
+			NationalityProbability: 0.7,
+			NationalityCandidates:  []NationalityCandidate{{"UA", 0.7}},
+		},
+	}
+	for _, p := range people {
+		id, err := store.SavePerson(context.Background(), p)
+		assert.NoError(t, err)
+		assert.Equal(t, p.ID, id)
+		p.EnrichmentStatus = EnrichmentDone
+	}
+
+	//Everyone who has UA among candidates
+	candidate := "UA"
+	peopleFromDB, err := store.GetPeople(context.Background(), &GetParams{Limit: 10, Candidate: &candidate})
+	assert.NoError(t, err)
+	assert.EqualValues(t, people, peopleFromDB)
+
+	//UA among top-3 candidates with probability > 0.2
+	top := 3
+	probability := 0.2
+	peopleFromDB, err = store.GetPeople(context.Background(), &GetParams{Limit: 10, Candidate: &candidate, CandidateTop: &top, CandidateProbability: &probability})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*Person{people[0], people[2]}, peopleFromDB)
+}
+
 func TestUpdatePerson(t *testing.T) {
 	//Initialize store
 	store, err := initStore()
@@ -272,9 +344,16 @@ func TestUpdatePerson(t *testing.T) {
 		Name:        "Ivan",
 		Surname:     "Ivanov",
 		Patronymic:  "Ivanovich",
-		Age:         30,
-		Gender:      "male",
-		Nationality: "russian",
+		Age:         ptr(30),
+		Gender:      ptr("male"),
+		Nationality: ptr("russian"),
+		CountryHint: "RU",
+
+		AgeSampleCount:         1000,
+		GenderProbability:      0.99,
+		NationalityProbability: 0.4,
+
+		EnrichmentStatus: EnrichmentDone,
 	}
 
 	//Save person to the db
@@ -283,8 +362,8 @@ func TestUpdatePerson(t *testing.T) {
 	assert.NotEmpty(t, id)
 
 	//Update person
-	person.Age = 1
-	person.Nationality = "american"
+	person.Age = ptr(1)
+	person.Nationality = ptr("american")
 	assert.NoError(t, store.UpdatePerson(context.Background(), &person))
 
 	//Check if the person has been updated
@@ -293,6 +372,309 @@ func TestUpdatePerson(t *testing.T) {
 	assert.EqualValues(t, person, *people[0])
 }
 
+func TestEnrichmentCache(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+	cache := NewEnrichmentCache(store.(*Store).db, store.(*Store).logger)
+
+	//Check that missing key is not found
+	_, ok, err := cache.GetCached(context.Background(), "ivan")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	//Save value and get it back
+	value := []byte(`{"age":42,"gender":"male","nationality":"RU"}`)
+	assert.NoError(t, cache.SetCached(context.Background(), "ivan", value, time.Hour))
+	cached, ok, err := cache.GetCached(context.Background(), "ivan")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.JSONEq(t, string(value), string(cached))
+
+	//Check that expired value is not returned
+	assert.NoError(t, cache.SetCached(context.Background(), "ivan", value, -time.Hour))
+	_, ok, err = cache.GetCached(context.Background(), "ivan")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	//Expired value is deleted once the purge interval passes
+	var count int
+	assert.NoError(t, cache.db.QueryRow("SELECT COUNT(*) FROM enrichment_cache;").Scan(&count))
+	assert.Equal(t, 1, count)
+	cache.lastPurge.Add(-int64(purgeInterval))
+	assert.NoError(t, cache.SetCached(context.Background(), "maria", value, time.Hour))
+	assert.NoError(t, cache.db.QueryRow("SELECT COUNT(*) FROM enrichment_cache;").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestEnrichmentQueue(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Save pending people, fields that are not provided are pending
+	for _, name := range []string{"Ivan", "Maria"} {
+		_, err := store.SavePerson(context.Background(), &Person{Name: name, Surname: "Ivanov", CountryHint: "RU", Age: ptr(30), AgeStatus: "provided", EnrichmentStatus: EnrichmentPending})
+		assert.NoError(t, err)
+	}
+	pending, err := store.GetPerson(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "provided", pending.AgeStatus)
+	assert.Equal(t, FieldPending, pending.GenderStatus)
+	assert.Equal(t, FieldPending, pending.NationalityStatus)
+
+	//Claimed people are hidden from other workers until the lease expires
+	claimed, err := store.ClaimPending(context.Background(), 1, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, "Ivan", claimed[0].Name)
+	assert.Equal(t, "RU", claimed[0].CountryHint)
+	assert.Equal(t, 1, claimed[0].EnrichmentAttempts)
+	claimed, err = store.ClaimPending(context.Background(), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, "Maria", claimed[0].Name)
+
+	//Complete the first person
+	assert.NoError(t, store.CompleteEnrichment(context.Background(), &Person{ID: 1, Age: ptr(30), Gender: ptr("male"), Nationality: ptr("RU"), CountryHint: "RU"}))
+	done := EnrichmentDone
+	people, err := store.GetPeople(context.Background(), &GetParams{Limit: 10, EnrichmentStatus: &done})
+	assert.NoError(t, err)
+	assert.Len(t, people, 1)
+	assert.Equal(t, 30, *people[0].Age)
+
+	//Failed person is retried after retryAt and dead-lettered without it
+	assert.NoError(t, store.FailEnrichment(context.Background(), 2, "provider is down", ptr(time.Now().Add(-time.Second))))
+	claimed, err = store.ClaimPending(context.Background(), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].EnrichmentAttempts)
+	assert.Equal(t, "provider is down", claimed[0].EnrichmentError)
+	assert.NoError(t, store.FailEnrichment(context.Background(), 2, "provider is down", nil))
+	dead := EnrichmentDead
+	people, err = store.GetPeople(context.Background(), &GetParams{Limit: 10, EnrichmentStatus: &dead})
+	assert.NoError(t, err)
+	assert.Len(t, people, 1)
+	assert.Equal(t, 2, people[0].ID)
+
+	//Pending fields of the dead-lettered person are failed and it is selected for re-enrichment of failed people
+	assert.Equal(t, "provided", people[0].AgeStatus)
+	assert.Equal(t, "failed", people[0].GenderStatus)
+	assert.Equal(t, "failed", people[0].NationalityStatus)
+	people, err = store.GetPeople(context.Background(), &GetParams{Limit: 10, Failed: ptr(true)})
+	assert.NoError(t, err)
+	assert.Len(t, people, 1)
+	assert.Equal(t, 2, people[0].ID)
+}
+
+func TestGetPeopleForReenrichment(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Save people enriched at different times
+	now := time.Now()
+	people := []*Person{
+		{Name: "Ivan", EnrichedAt: ptr(now.AddDate(0, 0, -60)), AgeStatus: "ok", GenderStatus: "ok", NationalityStatus: "ok"},
+		{Name: "Maria", EnrichedAt: ptr(now), AgeStatus: "ok", GenderStatus: "ok", NationalityStatus: "failed"},
+		{Name: "Olga", AgeStatus: "ok", GenderStatus: "ok", NationalityStatus: "ok"},
+		{Name: "Sergei", EnrichmentStatus: EnrichmentPending},
+	}
+	for _, p := range people {
+		_, err := store.SavePerson(context.Background(), p)
+		assert.NoError(t, err)
+	}
+	names := func(people []*Person) []string {
+		var names []string
+		for _, p := range people {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	//People enriched more than 30 days ago or never, pending people are skipped
+	before := now.AddDate(0, 0, -30)
+	peopleFromDB, err := store.GetPeople(context.Background(), &GetParams{Limit: 10, EnrichedBefore: &before})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ivan", "Olga"}, names(peopleFromDB))
+
+	//People with failed fields
+	failed := true
+	peopleFromDB, err = store.GetPeople(context.Background(), &GetParams{Limit: 10, Failed: &failed})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Maria"}, names(peopleFromDB))
+
+	//People selected by IDs
+	peopleFromDB, err = store.GetPeople(context.Background(), &GetParams{Limit: 10, IDs: []int{2, 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Maria", "Sergei"}, names(peopleFromDB))
+}
+
+func TestProvenance(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Save person with provenance of age and gender
+	fetchedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	person := Person{
+		Name:              "Ivan",
+		Age:               ptr(30),
+		Gender:            ptr("male"),
+		AgeProvenance:     &Provenance{Source: "agify", FetchedAt: fetchedAt, SampleCount: 1000},
+		GenderProvenance:  &Provenance{Source: "genderize", FetchedAt: fetchedAt, Probability: 0.99},
+		EnrichmentStatus:  EnrichmentDone,
+		NationalityStatus: "failed",
+	}
+	id, err := store.SavePerson(context.Background(), &person)
+	assert.NoError(t, err)
+
+	//Provenance is read back, missing provenance stays nil
+	people, err := store.GetPeople(context.Background(), &GetParams{Limit: 1, IDs: []int{id}})
+	assert.NoError(t, err)
+	assert.Equal(t, person.AgeProvenance, people[0].AgeProvenance)
+	assert.Equal(t, person.GenderProvenance, people[0].GenderProvenance)
+	assert.Nil(t, people[0].NationalityProvenance)
+
+	//Update replaces provenance and status only of the changed fields
+	edited := &Provenance{Source: SourceUpdate, FetchedAt: fetchedAt.AddDate(0, 1, 0), EditedBy: "operator"}
+	person.ID = id
+	person.Age = ptr(47)
+	person.AgeProvenance, person.GenderProvenance, person.NationalityProvenance = edited, edited, edited
+	person.AgeStatus, person.GenderStatus, person.NationalityStatus = "provided", "provided", ""
+	assert.NoError(t, store.UpdatePerson(context.Background(), &person))
+	people, err = store.GetPeople(context.Background(), &GetParams{Limit: 1, IDs: []int{id}})
+	assert.NoError(t, err)
+	assert.Equal(t, edited, people[0].AgeProvenance)
+	assert.Equal(t, "genderize", people[0].GenderProvenance.Source)
+	assert.Nil(t, people[0].NationalityProvenance)
+	assert.Equal(t, "provided", people[0].AgeStatus)
+	assert.Equal(t, "", people[0].GenderStatus)
+	assert.Equal(t, "failed", people[0].NationalityStatus)
+}
+
+func TestMissingProvenance(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Person without any provenance is saved and completed with NULL provenance
+	person := Person{Name: "Ivan", EnrichmentStatus: EnrichmentPending}
+	id, err := store.SavePerson(context.Background(), &person)
+	assert.NoError(t, err)
+	person.ID = id
+	person.AgeStatus, person.GenderStatus, person.NationalityStatus = "failed", "failed", "failed"
+	assert.NoError(t, store.CompleteEnrichment(context.Background(), &person))
+	saved, err := store.GetPerson(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Nil(t, saved.AgeProvenance)
+	assert.Nil(t, saved.GenderProvenance)
+	assert.Nil(t, saved.NationalityProvenance)
+}
+
+func TestMarshalProvenance(t *testing.T) {
+	//Missing provenance is an untyped nil, so that the driver sends NULL instead of an empty value
+	encoded, err := marshalProvenance(&Person{GenderProvenance: &Provenance{Source: "genderize"}})
+	assert.NoError(t, err)
+	assert.True(t, encoded[0] == nil)
+	assert.JSONEq(t, `{"source":"genderize","fetched_at":"0001-01-01T00:00:00Z"}`, string(encoded[1].([]byte)))
+	assert.True(t, encoded[2] == nil)
+}
+
+func TestGetPerson(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Save person to the db
+	person := Person{
+		Name:             "Ivan",
+		Surname:          "Ivanov",
+		Age:              ptr(30),
+		Gender:           ptr("male"),
+		AgeStatus:        "ok",
+		GenderStatus:     "ok",
+		EnrichmentStatus: EnrichmentDone,
+	}
+	id, err := store.SavePerson(context.Background(), &person)
+	assert.NoError(t, err)
+	person.ID = id
+
+	//Saved person is found by id
+	found, err := store.GetPerson(context.Background(), id)
+	assert.NoError(t, err)
+	assert.EqualValues(t, person, *found)
+
+	//Missing person is reported with ErrNotFound
+	_, err = store.GetPerson(context.Background(), id+1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMockStoreGetPerson(t *testing.T) {
+	store := NewMockStore()
+
+	person, err := store.GetPerson(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, person.ID)
+	_, err = store.GetPerson(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMissingPerson(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Save person to the db and delete it
+	id, err := store.SavePerson(context.Background(), &Person{Name: "Ivan", Surname: "Ivanov"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.DeletePerson(context.Background(), id))
+
+	//Deleted person can be neither deleted nor updated again
+	err = store.DeletePerson(context.Background(), id)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, errkind.ErrNotFound)
+	err = store.UpdatePerson(context.Background(), &Person{ID: id, Name: "Ivan", Surname: "Ivanov"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, errkind.ErrNotFound)
+
+	//Results of enrichment of the deleted person are not saved
+	assert.ErrorIs(t, store.CompleteEnrichment(context.Background(), &Person{ID: id}), ErrNotFound)
+	assert.ErrorIs(t, store.FailEnrichment(context.Background(), id, "provider is down", nil), ErrNotFound)
+}
+
+func TestInvalidPerson(t *testing.T) {
+	//Initialize store
+	store, err := initStore()
+	assert.NoError(t, err)
+
+	//Age out of range of the column is a validation error
+	_, err = store.SavePerson(context.Background(), &Person{Name: "Ivan", Surname: "Ivanov", Age: ptr(10000000000)})
+	assert.ErrorIs(t, err, errkind.ErrValidation)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		code pq.ErrorCode
+		kind error
+	}{
+		{"23505", errkind.ErrConflict},
+		{"40001", errkind.ErrConflict},
+		{"22003", errkind.ErrValidation},
+		{"23502", errkind.ErrValidation},
+	}
+	for _, test := range tests {
+		err := classify(&pq.Error{Code: test.code, Message: "message"})
+		assert.ErrorIs(t, err, test.kind)
+		assert.EqualError(t, err, "message")
+	}
+
+	//Other errors are returned as they are
+	assert.Equal(t, sql.ErrConnDone, classify(sql.ErrConnDone))
+	pqErr := &pq.Error{Code: "08006"}
+	assert.Equal(t, error(pqErr), classify(pqErr))
+}
+
 // initStore initializes store for tests
 func initStore() (Storer, error) {
 	//Load environment variables
@@ -332,3 +714,8 @@ func initStore() (Storer, error) {
 	//Returns the new storage
 	return New(db, sugar), nil
 }
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}